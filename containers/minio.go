@@ -0,0 +1,106 @@
+package containers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-faster/errors"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+type MinioConf struct {
+	Image   string
+	Name    string
+	Network string
+	// Bucket is created on start, "test" by default.
+	Bucket string
+}
+
+type MinioContainer struct {
+	Container testcontainers.Container
+	External  string
+	Internal  string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+func NewMinio(ctx context.Context, conf MinioConf) (*MinioContainer, error) {
+	const (
+		defaultImageName = "minio/minio:RELEASE.2024-01-16T16-07-38Z"
+		defaultPort      = "9000"
+		defaultBucket    = "test"
+	)
+	const (
+		accessKey = "minioadmin"
+		secretKey = "minioadmin"
+	)
+
+	bucket := defaultBucket
+	if conf.Bucket != "" {
+		bucket = conf.Bucket
+	}
+
+	containerReq := testcontainers.ContainerRequest{
+		Image: defaultImageName,
+		Env: map[string]string{
+			"MINIO_ROOT_USER":     accessKey,
+			"MINIO_ROOT_PASSWORD": secretKey,
+		},
+		// a directory in the data root is a bucket for MinIO
+		Entrypoint:   []string{"sh", "-c", fmt.Sprintf("mkdir -p /data/%s && minio server /data", bucket)},
+		ExposedPorts: []string{defaultPort},
+		WaitingFor:   wait.ForHTTP("/minio/health/ready").WithPort(defaultPort),
+	}
+
+	if conf.Network != "" {
+		containerReq.Networks = []string{conf.Network}
+		containerReq.NetworkAliases = map[string][]string{
+			conf.Network: {"minio-test"},
+		}
+	}
+
+	if conf.Image != "" {
+		containerReq.Image = conf.Image
+	}
+
+	if conf.Name != "" {
+		containerReq.Name = conf.Name
+	}
+
+	req := testcontainers.GenericContainerRequest{
+		ContainerRequest: containerReq,
+		Logger:           testcontainers.Logger,
+		Started:          true,
+	}
+
+	container, err := testcontainers.GenericContainer(ctx, req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start container")
+	}
+
+	mappedPort, err := container.MappedPort(ctx, defaultPort)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get exposed port for minio container")
+	}
+
+	networkIP, err := container.ContainerIP(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "get container IP")
+	}
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "get container host")
+	}
+
+	return &MinioContainer{
+		Container: container,
+		External:  fmt.Sprintf("http://%s:%s", host, mappedPort.Port()),
+		Internal:  fmt.Sprintf("http://%s:%s", networkIP, defaultPort),
+		Bucket:    bucket,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+	}, nil
+}
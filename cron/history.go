@@ -0,0 +1,94 @@
+package cron
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type RunStatus string
+
+const (
+	StatusSucceeded RunStatus = "succeeded"
+	StatusFailed    RunStatus = "failed"
+	StatusSkipped   RunStatus = "skipped"
+)
+
+type RunRecord struct {
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	Status    RunStatus     `json:"status"`
+	Error     string        `json:"error,omitempty"`
+	// Reason of skipping the run: overlap, lease_held or lease_error.
+	Reason string `json:"reason,omitempty"`
+}
+
+type JobStatus struct {
+	Name    string    `json:"name"`
+	Spec    string    `json:"spec"`
+	Next    time.Time `json:"next"`
+	Running bool      `json:"running"`
+	// Runs are the recent runs, the latest first.
+	Runs []RunRecord `json:"runs"`
+}
+
+// history keeps the recent runs in a ring buffer.
+type history struct {
+	mu      sync.Mutex
+	records []RunRecord
+	next    int
+	full    bool
+}
+
+func newHistory(size int) *history {
+	return &history{records: make([]RunRecord, max(size, 1))}
+}
+
+func (h *history) add(record RunRecord) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.records[h.next] = record
+	h.next = (h.next + 1) % len(h.records)
+	h.full = h.full || h.next == 0
+}
+
+func (h *history) list() []RunRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n := h.next
+	if h.full {
+		n = len(h.records)
+	}
+	records := make([]RunRecord, 0, n)
+	for i := 1; i <= n; i++ {
+		records = append(records, h.records[(h.next-i+len(h.records))%len(h.records)])
+	}
+	return records
+}
+
+// Handler serves the jobs of the scheduler as JSON, the job query parameter selects a single job.
+// It's meant to be mounted on metrics.HTTPServer.
+func Handler(s Scheduler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jobs := s.Jobs()
+		if name := r.URL.Query().Get("job"); name != "" {
+			filtered := jobs[:0]
+			for _, job := range jobs {
+				if job.Name == name {
+					filtered = append(filtered, job)
+				}
+			}
+			if len(filtered) == 0 {
+				http.Error(w, "job not found", http.StatusNotFound)
+				return
+			}
+			jobs = filtered
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(jobs)
+	})
+}
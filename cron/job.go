@@ -0,0 +1,66 @@
+package cron
+
+import (
+	"context"
+	"time"
+)
+
+// Locker grants leases to run singleton jobs, so one replica of a deployment runs each slot of the schedule.
+type Locker interface {
+	// Acquire takes the lease for ttl, it returns false if the lease is held by another replica.
+	Acquire(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// OverlapPolicy decides what happens when the job is due while its previous run is still running.
+type OverlapPolicy int
+
+const (
+	// OverlapAllow starts the run concurrently with the previous one.
+	OverlapAllow OverlapPolicy = iota
+	// OverlapSkip skips the run.
+	OverlapSkip
+	// OverlapQueueOne starts the run when the previous one finishes. Only one run waits,
+	// the following ones are skipped until it starts.
+	OverlapQueueOne
+)
+
+type JobOption func(o *jobOptions)
+
+type jobOptions struct {
+	name    string
+	locker  Locker
+	overlap OverlapPolicy
+	timeout time.Duration
+}
+
+// WithName sets the name of the job used in logs, spans and metrics. Names must be unique within the scheduler.
+// By default the spec is used, followed by a number if another job has the same spec.
+func WithName(name string) JobOption {
+	return func(o *jobOptions) {
+		o.name = name
+	}
+}
+
+// WithSingleton makes the job cluster-singleton: each run acquires the lease keyed by the job name
+// and the schedule slot, only the replica holding it executes the job. Singleton jobs must be named
+// with WithName, and the names must be unique across all services sharing the locker. Slots of @every schedules are aligned to multiples of the interval,
+// so replicas started at different times share them.
+func WithSingleton(locker Locker) JobOption {
+	return func(o *jobOptions) {
+		o.locker = locker
+	}
+}
+
+// WithOverlapPolicy sets what happens to runs due while the previous one is running, OverlapAllow by default.
+func WithOverlapPolicy(policy OverlapPolicy) JobOption {
+	return func(o *jobOptions) {
+		o.overlap = policy
+	}
+}
+
+// WithTimeout cancels context of every run after d.
+func WithTimeout(d time.Duration) JobOption {
+	return func(o *jobOptions) {
+		o.timeout = d
+	}
+}
@@ -0,0 +1,53 @@
+package cron
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const (
+	skippedOverlap    = "overlap"
+	skippedLeaseHeld  = "lease_held"
+	skippedLeaseError = "lease_error"
+)
+
+//nolint:gochecknoglobals // metrics are shared by all schedulers
+var (
+	lastSuccess = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cron_job_last_success_timestamp_seconds",
+			Help: "Unix time of the last successful run of the job",
+		},
+		[]string{"job"},
+	)
+
+	durations = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "cron_job_duration_seconds",
+			Help:    "Histogram of durations of job runs",
+			Buckets: prometheus.ExponentialBuckets(0.01, 4, 10),
+		},
+		[]string{"job"},
+	)
+
+	failures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cron_job_failures_total",
+			Help: "Total number of failed job runs",
+		},
+		[]string{"job"},
+	)
+
+	skipped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cron_job_skipped_total",
+			Help: "Total number of skipped job runs by reason: overlap, lease_held or lease_error",
+		},
+		[]string{"job", "reason"},
+	)
+)
+
+// RegisterMetrics registers metrics of all schedulers in the default registry. It must be called once.
+func RegisterMetrics() {
+	prometheus.MustRegister(lastSuccess)
+	prometheus.MustRegister(durations)
+	prometheus.MustRegister(failures)
+	prometheus.MustRegister(skipped)
+}
@@ -1,34 +1,106 @@
 package cron
 
 import (
+	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	cmnlogger "github.com/Justksenia/common/logger"
+	"github.com/Justksenia/common/tracer"
 	"github.com/go-faster/errors"
 	"github.com/robfig/cron/v3"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	// minLeaseTTL keeps leases of frequent jobs long enough to cover clock skew between replicas.
+	minLeaseTTL        = time.Minute
+	defaultHistorySize = 20
+)
+
+var (
+	// ErrPanic is returned for runs of a job which panicked.
+	ErrPanic        = errors.New("job panicked")
+	ErrDuplicateJob = errors.New("job with the same name is already added")
+	// ErrUnnamedSingleton is returned for singleton jobs without WithName, leases of unrelated jobs
+	// with the same spec would clash otherwise.
+	ErrUnnamedSingleton = errors.New("singleton job must be named")
 )
 
 type Job interface {
-	Run()
+	// Run executes the job. ctx is canceled when the scheduler is stopping.
+	Run(ctx context.Context) error
+}
+
+// JobFunc adapts a function to Job.
+type JobFunc func(ctx context.Context) error
+
+func (f JobFunc) Run(ctx context.Context) error {
+	return f(ctx)
 }
 
 type Scheduler interface {
-	AddJob(j Job, spec string) error
+	AddJob(j Job, spec string, opts ...JobOption) error
+
+	// Start runs the jobs in background. Runs get contexts derived from ctx, so it should carry the logger.
+	Start(ctx context.Context)
+	// Stop cancels contexts of running jobs and waits for them to return until ctx is done.
+	// No jobs are started after Stop.
+	Stop(ctx context.Context) error
+
+	// Jobs returns the state and recent runs of the jobs ordered by name.
+	Jobs() []JobStatus
+}
 
-	Start()
-	Stop()
+type Option func(s *scheduler)
+
+// WithHistorySize sets how many recent runs of every job are kept, 20 by default.
+func WithHistorySize(n int) Option {
+	return func(s *scheduler) {
+		s.historySize = n
+	}
 }
 
 type scheduler struct {
-	crons *cron.Cron
+	crons       *cron.Cron
+	historySize int
+
+	mu     sync.RWMutex
+	ctx    context.Context
+	cancel context.CancelFunc
+	jobs   map[string]*run
 }
 
-func NewCronScheduler() Scheduler {
+func NewCronScheduler(opts ...Option) Scheduler {
 	crons := cron.New()
-	return &scheduler{crons: crons}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &scheduler{
+		crons:       crons,
+		historySize: defaultHistorySize,
+		ctx:         ctx,
+		cancel:      cancel,
+		jobs:        make(map[string]*run),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-func (c *scheduler) AddJob(j Job, spec string) error {
+func (c *scheduler) AddJob(j Job, spec string, opts ...JobOption) error {
+	var options jobOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.locker != nil && options.name == "" {
+		return errors.Wrap(ErrUnnamedSingleton, spec)
+	}
+
 	splitted := strings.Split(spec, " ")
 	option := (cron.Minute | cron.Hour | cron.Dom | cron.Month) & (cron.Minute<<(len(splitted)) - 1)
 	if strings.HasPrefix(spec, "@") {
@@ -39,15 +111,263 @@ func (c *scheduler) AddJob(j Job, spec string) error {
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("parse spec %s", spec))
 	}
+	if every, ok := sch.(cron.ConstantDelaySchedule); ok && options.locker != nil {
+		sch = alignedSchedule(every)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	c.crons.Schedule(sch, j)
+	if options.name == "" {
+		options.name = c.defaultName(spec)
+	} else if _, ok := c.jobs[options.name]; ok {
+		return errors.Wrap(ErrDuplicateJob, options.name)
+	}
+	r := newRun(c, j, spec, options)
+	r.entry = c.crons.Schedule(sch, r)
+	c.jobs[options.name] = r
 	return nil
 }
 
-func (c *scheduler) Start() {
-	go c.crons.Run()
+// defaultName names the job by its spec, jobs with the same spec are numbered. It's called under the lock.
+func (c *scheduler) defaultName(spec string) string {
+	name := spec
+	for i := 2; ; i++ {
+		if _, ok := c.jobs[name]; !ok {
+			return name
+		}
+		name = fmt.Sprintf("%s #%d", spec, i)
+	}
+}
+
+func (c *scheduler) Start(ctx context.Context) {
+	c.mu.Lock()
+	c.cancel()
+	c.ctx, c.cancel = context.WithCancel(ctx)
+	c.mu.Unlock()
+
+	// Start marks the cron running before returning, unlike Run in a goroutine, so Stop right after it works
+	c.crons.Start()
+}
+
+func (c *scheduler) Stop(ctx context.Context) error {
+	done := c.crons.Stop()
+
+	c.mu.RLock()
+	c.cancel()
+	c.mu.RUnlock()
+
+	select {
+	case <-done.Done():
+		return nil
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "wait for running jobs")
+	}
+}
+
+func (c *scheduler) context() context.Context {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.ctx
+}
+
+// slot returns the time the running job was scheduled at and the interval to the next run.
+func (c *scheduler) slot(r *run) (time.Time, time.Duration) {
+	c.mu.RLock()
+	id := r.entry
+	c.mu.RUnlock()
+
+	entry := c.crons.Entry(id)
+	return entry.Prev, entry.Next.Sub(entry.Prev)
+}
+
+func (c *scheduler) Jobs() []JobStatus {
+	c.mu.RLock()
+	runs := make([]*run, 0, len(c.jobs))
+	for _, r := range c.jobs {
+		runs = append(runs, r)
+	}
+	c.mu.RUnlock()
+
+	jobs := make([]JobStatus, 0, len(runs))
+	for _, r := range runs {
+		entry := c.crons.Entry(r.entry)
+		jobs = append(jobs, JobStatus{
+			Name:    r.name,
+			Spec:    r.spec,
+			Next:    entry.Next,
+			Running: r.running(),
+			Runs:    r.history.list(),
+		})
+	}
+	slices.SortFunc(jobs, func(a, b JobStatus) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return jobs
+}
+
+// run executes the job in its own span and recovers its panics, so a failing job doesn't stop the others.
+type run struct {
+	scheduler *scheduler
+	name      string
+	spec      string
+	job       Job
+	locker    Locker
+	overlap   OverlapPolicy
+	timeout   time.Duration
+	entry     cron.EntryID
+	history   *history
+
+	// slot is held by the running job, queued is held by the job waiting for it.
+	slot   chan struct{}
+	queued chan struct{}
+	active atomic.Int32
+}
+
+func newRun(s *scheduler, j Job, spec string, options jobOptions) *run {
+	return &run{
+		scheduler: s,
+		name:      options.name,
+		spec:      spec,
+		job:       j,
+		locker:    options.locker,
+		overlap:   options.overlap,
+		timeout:   options.timeout,
+		history:   newHistory(s.historySize),
+		slot:      make(chan struct{}, 1),
+		queued:    make(chan struct{}, 1),
+	}
+}
+
+func (r *run) Run() {
+	ctx, span := tracer.StartSpan(r.scheduler.context(), "cron."+r.name, trace.SpanKindInternal)
+	defer span.End()
+
+	logger := cmnlogger.FromContext(ctx).With(zap.String("job", r.name))
+	started := time.Now()
+
+	// the slot is taken before waiting for the previous run, which moves the schedule on
+	var (
+		slot     time.Time
+		interval time.Duration
+	)
+	if r.locker != nil {
+		slot, interval = r.scheduler.slot(r)
+		logger = logger.With(zap.Time("slot", slot))
+	}
+
+	if !r.enter(ctx) {
+		logger.Warn("run skipped, previous run is still running")
+		r.skip(started, skippedOverlap)
+		return
+	}
+	defer r.leave()
+
+	if r.locker != nil {
+		acquired, err := r.locker.Acquire(ctx, leaseKey(r.name, slot), max(interval, minLeaseTTL))
+		if err != nil {
+			logger.Warn("slot missed, failed to acquire lease", zap.Error(span.Error(err)))
+			r.skip(started, skippedLeaseError)
+			return
+		}
+		if !acquired {
+			logger.Debug("slot skipped, lease is held by another replica")
+			r.skip(started, skippedLeaseHeld)
+			return
+		}
+	}
+
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := r.execute(ctx)
+	duration := time.Since(start)
+	durations.WithLabelValues(r.name).Observe(duration.Seconds())
+
+	record := RunRecord{StartedAt: start, Duration: duration, Status: StatusSucceeded}
+	if err != nil {
+		logger.Error("job failed", zap.Error(span.Error(err)))
+		failures.WithLabelValues(r.name).Inc()
+		record.Status, record.Error = StatusFailed, err.Error()
+	} else {
+		lastSuccess.WithLabelValues(r.name).Set(float64(time.Now().Unix()))
+	}
+	r.history.add(record)
+}
+
+// enter waits for the previous run according to the overlap policy, it returns false if the run is skipped.
+func (r *run) enter(ctx context.Context) bool {
+	switch r.overlap {
+	case OverlapSkip:
+		select {
+		case r.slot <- struct{}{}:
+		default:
+			return false
+		}
+	case OverlapQueueOne:
+		select {
+		case r.slot <- struct{}{}:
+		default:
+			select {
+			case r.queued <- struct{}{}:
+			default:
+				return false
+			}
+			defer func() { <-r.queued }()
+
+			select {
+			case r.slot <- struct{}{}:
+			case <-ctx.Done():
+				return false
+			}
+		}
+	case OverlapAllow:
+	}
+
+	r.active.Add(1)
+	return true
+}
+
+func (r *run) leave() {
+	r.active.Add(-1)
+	if r.overlap != OverlapAllow {
+		<-r.slot
+	}
+}
+
+func (r *run) running() bool {
+	return r.active.Load() > 0
+}
+
+func (r *run) skip(started time.Time, reason string) {
+	skipped.WithLabelValues(r.name, reason).Inc()
+	r.history.add(RunRecord{StartedAt: started, Status: StatusSkipped, Reason: reason})
+}
+
+func (r *run) execute(ctx context.Context) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			cmnlogger.FromContext(ctx).Error("job panicked", zap.String("job", r.name), zap.Any("panic", p), zap.StackSkip("stack", 1))
+			err = errors.Wrap(ErrPanic, fmt.Sprint(p))
+		}
+	}()
+
+	return r.job.Run(ctx)
+}
+
+// alignedSchedule runs at multiples of the delay, unlike cron.ConstantDelaySchedule counting it from the start,
+// so replicas started at different times share slots of singleton jobs.
+type alignedSchedule cron.ConstantDelaySchedule
+
+func (s alignedSchedule) Next(t time.Time) time.Time {
+	return t.Truncate(s.Delay).Add(s.Delay)
 }
 
-func (c *scheduler) Stop() {
-	c.crons.Stop()
+func leaseKey(name string, slot time.Time) string {
+	return fmt.Sprintf("%s-%d", name, slot.Unix())
 }
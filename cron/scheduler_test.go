@@ -1,14 +1,26 @@
 package cron
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/go-faster/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type idleJob struct{}
 
-func (*idleJob) Run() {}
+func (*idleJob) Run(context.Context) error { return nil }
 
 func TestParseSpecification(t *testing.T) {
 	testCases := []struct {
@@ -51,3 +63,319 @@ func TestParseSpecification(t *testing.T) {
 		})
 	}
 }
+
+func TestRun(t *testing.T) {
+	errJob := errors.New("job")
+
+	testCases := []struct {
+		name string
+		job  JobFunc
+		err  error
+	}{
+		{name: "success", job: func(context.Context) error { return nil }},
+		{name: "error", job: func(context.Context) error { return errJob }, err: errJob},
+		{name: "panic", job: func(context.Context) error { panic("boom") }, err: ErrPanic},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newRun(&scheduler{historySize: 1}, tc.job, "@every 1s", jobOptions{name: tc.name})
+			err := r.execute(context.Background())
+			if tc.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestStop(t *testing.T) {
+	var (
+		started  = make(chan struct{})
+		finished atomic.Bool
+		release  = make(chan struct{})
+	)
+	sch := NewCronScheduler()
+	require.NoError(t, sch.AddJob(JobFunc(func(ctx context.Context) error {
+		select {
+		case started <- struct{}{}:
+		default:
+			return nil
+		}
+		<-ctx.Done()
+		<-release
+		finished.Store(true)
+		return ctx.Err()
+	}), "@every 1s", WithName("blocking")))
+	sch.Start(context.Background())
+
+	select {
+	case <-started:
+	case <-time.After(3 * time.Second):
+		t.Fatal("job wasn't started")
+	}
+
+	// the job doesn't return in time
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, sch.Stop(ctx), context.DeadlineExceeded)
+
+	close(release)
+	require.NoError(t, sch.Stop(context.Background()))
+	assert.True(t, finished.Load())
+}
+
+func TestStopRightAfterStart(t *testing.T) {
+	var runs atomic.Int32
+	sch := NewCronScheduler()
+	require.NoError(t, sch.AddJob(JobFunc(func(context.Context) error {
+		runs.Add(1)
+		return nil
+	}), "@every 1s"))
+
+	sch.Start(context.Background())
+	require.NoError(t, sch.Stop(context.Background()))
+
+	time.Sleep(1500 * time.Millisecond)
+	assert.Zero(t, runs.Load())
+}
+
+type memoryLocker struct {
+	mu     sync.Mutex
+	leases map[string]struct{}
+}
+
+func (l *memoryLocker) Acquire(_ context.Context, key string, _ time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.leases[key]; ok {
+		return false, nil
+	}
+	l.leases[key] = struct{}{}
+	return true, nil
+}
+
+func TestSingleton(t *testing.T) {
+	var (
+		locker = &memoryLocker{leases: map[string]struct{}{}}
+		runs   atomic.Int32
+	)
+	job := JobFunc(func(context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+
+	schedulers := make([]Scheduler, 3)
+	for i := range schedulers {
+		schedulers[i] = NewCronScheduler()
+		require.NoError(t, schedulers[i].AddJob(job, "@every 1s", WithName("singleton"), WithSingleton(locker)))
+		schedulers[i].Start(context.Background())
+	}
+
+	time.Sleep(2500 * time.Millisecond)
+	for _, sch := range schedulers {
+		require.NoError(t, sch.Stop(context.Background()))
+	}
+	// replicas started within the same second share slots
+	assert.InDelta(t, 2, runs.Load(), 1)
+	assert.Len(t, locker.leases, int(runs.Load()))
+}
+
+func TestSingleton_StartedAtDifferentTimes(t *testing.T) {
+	locker := &memoryLocker{leases: map[string]struct{}{}}
+	job := JobFunc(func(context.Context) error { return nil })
+
+	schedulers := make([]Scheduler, 2)
+	for i := range schedulers {
+		if i > 0 {
+			time.Sleep(time.Second)
+		}
+		schedulers[i] = NewCronScheduler()
+		require.NoError(t, schedulers[i].AddJob(job, "@every 2s", WithName("singleton"), WithSingleton(locker)))
+		schedulers[i].Start(context.Background())
+	}
+
+	time.Sleep(3500 * time.Millisecond)
+	var skipped int
+	for _, sch := range schedulers {
+		require.NoError(t, sch.Stop(context.Background()))
+		for _, run := range sch.Jobs()[0].Runs {
+			if run.Reason == skippedLeaseHeld {
+				skipped++
+			}
+		}
+	}
+
+	// slots are aligned to the interval, so the replicas compete for the same leases
+	assert.Positive(t, skipped)
+	require.NotEmpty(t, locker.leases)
+	for key := range locker.leases {
+		slot, err := strconv.ParseInt(strings.TrimPrefix(key, "singleton-"), 10, 64)
+		require.NoError(t, err)
+		assert.Zero(t, slot%2, key)
+	}
+}
+
+func TestSingleton_SameSpec(t *testing.T) {
+	locker := &memoryLocker{leases: map[string]struct{}{}}
+	var runs [2]atomic.Int32
+
+	schedulers := make([]Scheduler, 2)
+	for i := range schedulers {
+		job := JobFunc(func(context.Context) error {
+			runs[i].Add(1)
+			return nil
+		})
+		schedulers[i] = NewCronScheduler()
+		require.ErrorIs(t, schedulers[i].AddJob(job, "@every 1s", WithSingleton(locker)), ErrUnnamedSingleton)
+		require.NoError(t, schedulers[i].AddJob(job, "@every 1s", WithName(fmt.Sprintf("job-%d", i)), WithSingleton(locker)))
+		schedulers[i].Start(context.Background())
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+	for _, sch := range schedulers {
+		require.NoError(t, sch.Stop(context.Background()))
+	}
+	// unrelated jobs with the same spec don't take each other's leases
+	for i := range runs {
+		assert.Positive(t, runs[i].Load(), i)
+	}
+}
+
+func TestAlignedSchedule(t *testing.T) {
+	schedule := alignedSchedule{Delay: time.Minute}
+	now := time.Date(2024, 1, 1, 10, 30, 15, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 1, 1, 10, 31, 0, 0, time.UTC), schedule.Next(now))
+	assert.Equal(t, time.Date(2024, 1, 1, 10, 32, 0, 0, time.UTC), schedule.Next(schedule.Next(now)))
+}
+
+func TestOverlapPolicy(t *testing.T) {
+	testCases := []struct {
+		name    string
+		policy  OverlapPolicy
+		runs    int32
+		skipped int
+	}{
+		{name: "allow", policy: OverlapAllow, runs: 3},
+		{name: "skip", policy: OverlapSkip, runs: 1, skipped: 2},
+		{name: "queue one", policy: OverlapQueueOne, runs: 2, skipped: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				runs    atomic.Int32
+				release = make(chan struct{})
+			)
+			sch := NewCronScheduler()
+			require.NoError(t, sch.AddJob(JobFunc(func(context.Context) error {
+				runs.Add(1)
+				<-release
+				return nil
+			}), "@every 1s", WithName(tc.name), WithOverlapPolicy(tc.policy)))
+
+			r := sch.(*scheduler).jobs[tc.name]
+			var wg sync.WaitGroup
+			for i := 0; i < 3; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					r.Run()
+				}()
+				// let the runs come in order
+				time.Sleep(20 * time.Millisecond)
+			}
+			assert.True(t, sch.Jobs()[0].Running)
+			close(release)
+			wg.Wait()
+
+			assert.Equal(t, tc.runs, runs.Load())
+			var skippedRuns int
+			for _, record := range sch.Jobs()[0].Runs {
+				if record.Status == StatusSkipped {
+					skippedRuns++
+					assert.Equal(t, skippedOverlap, record.Reason)
+				}
+			}
+			assert.Equal(t, tc.skipped, skippedRuns)
+			assert.False(t, sch.Jobs()[0].Running)
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	sch := NewCronScheduler()
+	require.NoError(t, sch.AddJob(JobFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}), "@every 1s", WithName("slow"), WithTimeout(10*time.Millisecond)))
+
+	sch.(*scheduler).jobs["slow"].Run()
+
+	runs := sch.Jobs()[0].Runs
+	require.Len(t, runs, 1)
+	assert.Equal(t, StatusFailed, runs[0].Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), runs[0].Error)
+}
+
+func TestHistory(t *testing.T) {
+	h := newHistory(3)
+	assert.Empty(t, h.list())
+
+	for i := 1; i <= 4; i++ {
+		h.add(RunRecord{Duration: time.Duration(i)})
+	}
+	var durations []time.Duration
+	for _, record := range h.list() {
+		durations = append(durations, record.Duration)
+	}
+	assert.Equal(t, []time.Duration{4, 3, 2}, durations)
+}
+
+func TestAddJob_Names(t *testing.T) {
+	sch := NewCronScheduler()
+	require.NoError(t, sch.AddJob(&idleJob{}, "@every 1m"))
+	require.NoError(t, sch.AddJob(&idleJob{}, "@every 1m"))
+	require.NoError(t, sch.AddJob(&idleJob{}, "@every 1m", WithName("idle")))
+	require.ErrorIs(t, sch.AddJob(&idleJob{}, "@every 1h", WithName("idle")), ErrDuplicateJob)
+
+	var names []string
+	for _, job := range sch.Jobs() {
+		names = append(names, job.Name)
+	}
+	assert.Equal(t, []string{"@every 1m", "@every 1m #2", "idle"}, names)
+}
+
+func TestHandler(t *testing.T) {
+	errJob := errors.New("job")
+	sch := NewCronScheduler()
+	require.NoError(t, sch.AddJob(JobFunc(func(context.Context) error { return errJob }), "@every 1s", WithName("failing")))
+	require.NoError(t, sch.AddJob(&idleJob{}, "@every 1m", WithName("idle")))
+	require.ErrorIs(t, sch.AddJob(&idleJob{}, "@every 1m", WithName("idle")), ErrDuplicateJob)
+	sch.(*scheduler).jobs["failing"].Run()
+
+	handler := Handler(sch)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var jobs []JobStatus
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&jobs))
+	require.Len(t, jobs, 2)
+	assert.Equal(t, "failing", jobs[0].Name)
+	assert.Equal(t, "idle", jobs[1].Name)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?job=failing", nil))
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&jobs))
+	require.Len(t, jobs, 1)
+	require.Len(t, jobs[0].Runs, 1)
+	assert.Equal(t, StatusFailed, jobs[0].Runs[0].Status)
+	assert.Equal(t, errJob.Error(), jobs[0].Runs[0].Error)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?job=missing", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,43 @@
+package purchase
+
+import (
+	"github.com/Justksenia/common/schema/kafka/gen"
+	"github.com/go-faster/errors"
+)
+
+var ErrEmptyPurchaseID = errors.New("empty purchase id")
+
+// NewUpdateStatusEvent builds UpdatePurchaseEvent for the status change, if the transition is allowed.
+func NewUpdateStatusEvent(purchaseID string, from, to Status) (*gen.UpdatePurchaseEvent, error) {
+	if purchaseID == "" {
+		return nil, ErrEmptyPurchaseID
+	}
+	if err := from.Transit(to); err != nil {
+		return nil, err
+	}
+
+	return &gen.UpdatePurchaseEvent{
+		PurchaseId: purchaseID,
+		EventType:  gen.UpdatePurchaseEventType_UPDATE_PURCHASE_EVENT_TYPE_UPDATE_STATUS,
+		Payload: &gen.UpdatePurchaseEvent_UpdateStatusEvent{
+			UpdateStatusEvent: &gen.UpdatePurchaseStatusEvent{Status: to.Proto()},
+		},
+	}, nil
+}
+
+func NewSendCreativeEvent(purchaseID string, creative *gen.UpdatePurchaseSendCreativeEvent) (*gen.UpdatePurchaseEvent, error) {
+	if purchaseID == "" {
+		return nil, ErrEmptyPurchaseID
+	}
+	if creative == nil {
+		return nil, errors.New("empty creative")
+	}
+
+	return &gen.UpdatePurchaseEvent{
+		PurchaseId: purchaseID,
+		EventType:  gen.UpdatePurchaseEventType_UPDATE_PURCHASE_EVENT_TYPE_SEND_CREATIVE,
+		Payload: &gen.UpdatePurchaseEvent_SendCreativeEvent{
+			SendCreativeEvent: creative,
+		},
+	}, nil
+}
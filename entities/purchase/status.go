@@ -0,0 +1,131 @@
+package purchase
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Justksenia/common/schema/kafka/gen"
+	"github.com/go-faster/errors"
+)
+
+type Status string
+
+const (
+	StatusUnspecified  Status = ""
+	StatusPendingAwait Status = "pending_await"
+	StatusPlanned      Status = "planned"
+	StatusDeclined     Status = "declined"
+)
+
+var (
+	ErrUnknownStatus       = errors.New("unknown purchase status")
+	ErrIllegalTransition   = errors.New("illegal purchase status transition")
+	ErrSameStatusTransited = errors.Wrap(ErrIllegalTransition, "status is not changed")
+)
+
+// IllegalTransitionError is returned when a purchase can't move from one status to another.
+type IllegalTransitionError struct {
+	From Status
+	To   Status
+}
+
+func (e *IllegalTransitionError) Error() string {
+	return fmt.Sprintf("%s: %q -> %q", ErrIllegalTransition, e.From, e.To)
+}
+
+func (e *IllegalTransitionError) Is(target error) bool {
+	return target == ErrIllegalTransition //nolint:errorlint // sentinel comparison
+}
+
+// transitions is the state machine of purchase statuses. Declined is terminal.
+//
+//nolint:gochecknoglobals // state machine definition
+var transitions = map[Status][]Status{
+	StatusUnspecified:  {StatusPendingAwait, StatusPlanned},
+	StatusPendingAwait: {StatusPlanned, StatusDeclined},
+	StatusPlanned:      {StatusPendingAwait, StatusDeclined},
+	StatusDeclined:     {},
+}
+
+//nolint:gochecknoglobals // enum mapping
+var (
+	statusToProto = map[Status]gen.PurchaseStatus{
+		StatusUnspecified:  gen.PurchaseStatus_PURCHASE_STATUS_UNSPECIFIED,
+		StatusPendingAwait: gen.PurchaseStatus_PURCHASE_STATUS_PENDING_AWAIT,
+		StatusPlanned:      gen.PurchaseStatus_PURCHASE_STATUS_PLANNED,
+		StatusDeclined:     gen.PurchaseStatus_PURCHASE_STATUS_DECLINED,
+	}
+	statusFromProto = map[gen.PurchaseStatus]Status{
+		gen.PurchaseStatus_PURCHASE_STATUS_UNSPECIFIED:   StatusUnspecified,
+		gen.PurchaseStatus_PURCHASE_STATUS_PENDING_AWAIT: StatusPendingAwait,
+		gen.PurchaseStatus_PURCHASE_STATUS_PLANNED:       StatusPlanned,
+		gen.PurchaseStatus_PURCHASE_STATUS_DECLINED:      StatusDeclined,
+	}
+)
+
+// ParseStatus parses the string form of a status, as used in ChangePurchaseStatusEvent.purchase_status.
+// Both the short form ("planned") and the proto enum name ("PURCHASE_STATUS_PLANNED") are accepted.
+// An empty string is missing input, so it's an error rather than StatusUnspecified.
+func ParseStatus(s string) (Status, error) {
+	if s == "" {
+		return StatusUnspecified, errors.Wrap(ErrUnknownStatus, "empty status")
+	}
+	if v, ok := gen.PurchaseStatus_value[strings.ToUpper(s)]; ok {
+		return StatusFromProto(gen.PurchaseStatus(v))
+	}
+
+	status := Status(strings.ToLower(s))
+	if _, ok := transitions[status]; !ok {
+		return StatusUnspecified, errors.Wrap(ErrUnknownStatus, s)
+	}
+	return status, nil
+}
+
+func StatusFromProto(s gen.PurchaseStatus) (Status, error) {
+	status, ok := statusFromProto[s]
+	if !ok {
+		return StatusUnspecified, errors.Wrap(ErrUnknownStatus, s.String())
+	}
+	return status, nil
+}
+
+func (s Status) String() string {
+	return string(s)
+}
+
+func (s Status) Proto() gen.PurchaseStatus {
+	return statusToProto[s]
+}
+
+func (s Status) Validate() error {
+	if _, ok := transitions[s]; !ok {
+		return errors.Wrap(ErrUnknownStatus, s.String())
+	}
+	return nil
+}
+
+func (s Status) CanTransitTo(to Status) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transit checks whether the status can be changed from s to the given one.
+func (s Status) Transit(to Status) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	if err := to.Validate(); err != nil {
+		return err
+	}
+	if s == to {
+		return ErrSameStatusTransited
+	}
+	if !s.CanTransitTo(to) {
+		return &IllegalTransitionError{From: s, To: to}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package purchase
+
+import (
+	"testing"
+
+	"github.com/Justksenia/common/schema/kafka/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatus_Transit(t *testing.T) {
+	testCases := []struct {
+		name        string
+		from        Status
+		to          Status
+		expectedErr error
+	}{
+		{
+			name: "new purchase awaits",
+			from: StatusUnspecified,
+			to:   StatusPendingAwait,
+		},
+		{
+			name: "pending purchase planned",
+			from: StatusPendingAwait,
+			to:   StatusPlanned,
+		},
+		{
+			name: "planned purchase declined",
+			from: StatusPlanned,
+			to:   StatusDeclined,
+		},
+		{
+			name:        "declined is terminal",
+			from:        StatusDeclined,
+			to:          StatusPlanned,
+			expectedErr: ErrIllegalTransition,
+		},
+		{
+			name:        "same status",
+			from:        StatusPlanned,
+			to:          StatusPlanned,
+			expectedErr: ErrSameStatusTransited,
+		},
+		{
+			name:        "unknown status",
+			from:        StatusPlanned,
+			to:          Status("published"),
+			expectedErr: ErrUnknownStatus,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.ErrorIs(t, tc.from.Transit(tc.to), tc.expectedErr)
+		})
+	}
+}
+
+func TestIllegalTransitionError(t *testing.T) {
+	err := StatusDeclined.Transit(StatusPendingAwait)
+
+	var transitionErr *IllegalTransitionError
+	require.ErrorAs(t, err, &transitionErr)
+	assert.Equal(t, StatusDeclined, transitionErr.From)
+	assert.Equal(t, StatusPendingAwait, transitionErr.To)
+}
+
+func TestParseStatus(t *testing.T) {
+	testCases := []struct {
+		name        string
+		value       string
+		expected    Status
+		expectedErr error
+	}{
+		{name: "short form", value: "planned", expected: StatusPlanned},
+		{name: "proto form", value: "PURCHASE_STATUS_PENDING_AWAIT", expected: StatusPendingAwait},
+		{name: "unknown", value: "unknown", expectedErr: ErrUnknownStatus},
+		{name: "empty", value: "", expectedErr: ErrUnknownStatus},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			status, err := ParseStatus(tc.value)
+			assert.ErrorIs(t, err, tc.expectedErr)
+			assert.Equal(t, tc.expected, status)
+		})
+	}
+}
+
+func TestNewUpdateStatusEvent(t *testing.T) {
+	event, err := NewUpdateStatusEvent("purchase", StatusPendingAwait, StatusDeclined)
+	require.NoError(t, err)
+	assert.Equal(t, gen.UpdatePurchaseEventType_UPDATE_PURCHASE_EVENT_TYPE_UPDATE_STATUS, event.GetEventType())
+	assert.Equal(t, gen.PurchaseStatus_PURCHASE_STATUS_DECLINED, event.GetUpdateStatusEvent().GetStatus())
+
+	_, err = NewUpdateStatusEvent("purchase", StatusDeclined, StatusPlanned)
+	assert.ErrorIs(t, err, ErrIllegalTransition)
+
+	_, err = NewUpdateStatusEvent("", StatusPendingAwait, StatusPlanned)
+	assert.ErrorIs(t, err, ErrEmptyPurchaseID)
+}
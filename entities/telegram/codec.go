@@ -0,0 +1,178 @@
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/go-faster/errors"
+)
+
+var (
+	ErrUnknownMarkupVersion = errors.New("unknown markup version")
+	ErrUnknownMarkupType    = errors.New("unknown markup type")
+	ErrNoUpgradePath        = errors.New("no upgrade path to the latest markup version")
+)
+
+// MarkupCodec decodes and encodes payload of a single markup version.
+type MarkupCodec struct {
+	Version MarkupVersion
+	Type    reflect.Type
+	Decode  func(payload string) (any, error)
+	Encode  func(v any) (string, error)
+}
+
+// JSONMarkupCodec returns codec which stores *T as JSON payload.
+func JSONMarkupCodec[T any](version MarkupVersion) MarkupCodec {
+	return MarkupCodec{
+		Version: version,
+		Type:    reflect.TypeOf((*T)(nil)),
+		Decode: func(payload string) (any, error) {
+			v := new(T)
+			if err := json.Unmarshal([]byte(payload), v); err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("decode markup %s", version))
+			}
+			return v, nil
+		},
+		Encode: func(v any) (string, error) {
+			bytes, err := json.Marshal(v)
+			if err != nil {
+				return "", errors.Wrap(err, fmt.Sprintf("encode markup %s", version))
+			}
+			return string(bytes), nil
+		},
+	}
+}
+
+// MarkupUpgrade converts decoded markup of one version to the next one.
+type MarkupUpgrade struct {
+	From    MarkupVersion
+	To      MarkupVersion
+	Upgrade func(v any) (any, error)
+}
+
+// MarkupRegistry knows how to decode every markup version and how to migrate it to the latest one.
+type MarkupRegistry struct {
+	latest   MarkupVersion
+	codecs   map[MarkupVersion]MarkupCodec
+	types    map[reflect.Type]MarkupVersion
+	upgrades map[MarkupVersion]MarkupUpgrade
+}
+
+// NewMarkupRegistry creates registry, where the latest version is the one new markups are encoded with.
+func NewMarkupRegistry(latest MarkupCodec, codecs ...MarkupCodec) *MarkupRegistry {
+	r := &MarkupRegistry{
+		latest:   latest.Version,
+		codecs:   make(map[MarkupVersion]MarkupCodec, len(codecs)+1),
+		types:    make(map[reflect.Type]MarkupVersion, len(codecs)+1),
+		upgrades: make(map[MarkupVersion]MarkupUpgrade),
+	}
+	for _, codec := range append(codecs, latest) {
+		r.codecs[codec.Version] = codec
+		r.types[codec.Type] = codec.Version
+	}
+	return r
+}
+
+func (r *MarkupRegistry) RegisterUpgrade(upgrade MarkupUpgrade) {
+	r.upgrades[upgrade.From] = upgrade
+}
+
+func (r *MarkupRegistry) Latest() MarkupVersion {
+	return r.latest
+}
+
+// Decode returns the concrete type of the markup version, e.g. *MarkupV1 for 1.0.0.
+func (r *MarkupRegistry) Decode(m Markup) (any, error) {
+	codec, ok := r.codecs[m.Version]
+	if !ok {
+		return nil, errors.Wrap(ErrUnknownMarkupVersion, string(m.Version))
+	}
+	return codec.Decode(m.Payload)
+}
+
+// DecodeLatest decodes the markup and upgrades it to the latest version.
+func (r *MarkupRegistry) DecodeLatest(m Markup) (any, error) {
+	v, err := r.Decode(m)
+	if err != nil {
+		return nil, err
+	}
+	return r.upgrade(m.Version, v)
+}
+
+// Encode upgrades v to the latest version if needed and wraps it into the markup envelope.
+func (r *MarkupRegistry) Encode(v any) (Markup, error) {
+	version, ok := r.types[reflect.TypeOf(v)]
+	if !ok {
+		return Markup{}, errors.Wrap(ErrUnknownMarkupType, fmt.Sprintf("%T", v))
+	}
+
+	latest, err := r.upgrade(version, v)
+	if err != nil {
+		return Markup{}, err
+	}
+
+	payload, err := r.codecs[r.latest].Encode(latest)
+	if err != nil {
+		return Markup{}, err
+	}
+	return Markup{Version: r.latest, Payload: payload}, nil
+}
+
+// DecodeString decodes markup envelope serialized with Markup.ToString and upgrades it to the latest version.
+func (r *MarkupRegistry) DecodeString(s string) (any, error) {
+	var m Markup
+	if err := m.FromString(s); err != nil {
+		return nil, errors.Wrap(err, "decode markup envelope")
+	}
+	return r.DecodeLatest(m)
+}
+
+// EncodeString encodes v with the latest version and serializes the envelope.
+func (r *MarkupRegistry) EncodeString(v any) (string, error) {
+	m, err := r.Encode(v)
+	if err != nil {
+		return "", err
+	}
+	return m.ToString()
+}
+
+func (r *MarkupRegistry) upgrade(version MarkupVersion, v any) (any, error) {
+	visited := make(map[MarkupVersion]struct{})
+	for version != r.latest {
+		if _, ok := visited[version]; ok {
+			return nil, errors.Wrap(ErrNoUpgradePath, fmt.Sprintf("cycle at %s", version))
+		}
+		visited[version] = struct{}{}
+
+		upgrade, ok := r.upgrades[version]
+		if !ok {
+			return nil, errors.Wrap(ErrNoUpgradePath, string(version))
+		}
+
+		var err error
+		if v, err = upgrade.Upgrade(v); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("upgrade markup %s to %s", upgrade.From, upgrade.To))
+		}
+		version = upgrade.To
+	}
+	return v, nil
+}
+
+//nolint:gochecknoglobals // default registry
+var defaultMarkupRegistry = NewMarkupRegistry(JSONMarkupCodec[MarkupV1](MarkupVersion100))
+
+// DefaultMarkupRegistry returns registry with all markup versions known to this module.
+func DefaultMarkupRegistry() *MarkupRegistry {
+	return defaultMarkupRegistry
+}
+
+// DecodeMarkup decodes the markup with the default registry and upgrades it to the latest version.
+func DecodeMarkup(m Markup) (any, error) {
+	return defaultMarkupRegistry.DecodeLatest(m)
+}
+
+// EncodeMarkup encodes v with the latest version of the default registry.
+func EncodeMarkup(v any) (Markup, error) {
+	return defaultMarkupRegistry.Encode(v)
+}
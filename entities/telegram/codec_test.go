@@ -0,0 +1,81 @@
+package telegram
+
+import (
+	"testing"
+
+	"github.com/Justksenia/common/entities/telegram/markup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type markupV0 struct {
+	Text string `json:"text"`
+}
+
+const markupVersion090 MarkupVersion = "0.9.0"
+
+func newTestRegistry() *MarkupRegistry {
+	r := NewMarkupRegistry(
+		JSONMarkupCodec[MarkupV1](MarkupVersion100),
+		JSONMarkupCodec[markupV0](markupVersion090),
+	)
+	r.RegisterUpgrade(MarkupUpgrade{
+		From: markupVersion090,
+		To:   MarkupVersion100,
+		Upgrade: func(v any) (any, error) {
+			return &MarkupV1{Message: v.(*markupV0).Text}, nil //nolint:forcetypeassert // registered type
+		},
+	})
+	return r
+}
+
+func TestMarkupRegistry_Decode(t *testing.T) {
+	r := newTestRegistry()
+
+	v, err := r.Decode(Markup{Version: markupVersion090, Payload: `{"text":"old"}`})
+	require.NoError(t, err)
+	assert.Equal(t, &markupV0{Text: "old"}, v)
+
+	v, err = r.DecodeLatest(Markup{Version: markupVersion090, Payload: `{"text":"old"}`})
+	require.NoError(t, err)
+	assert.Equal(t, &MarkupV1{Message: "old"}, v)
+
+	_, err = r.Decode(Markup{Version: "2.0.0", Payload: "{}"})
+	assert.ErrorIs(t, err, ErrUnknownMarkupVersion)
+}
+
+func TestMarkupRegistry_Encode(t *testing.T) {
+	r := newTestRegistry()
+
+	m, err := r.Encode(&markupV0{Text: "old"})
+	require.NoError(t, err)
+	assert.Equal(t, MarkupVersion100, m.Version)
+	assert.JSONEq(t, `{"message":"old"}`, m.Payload)
+
+	_, err = r.Encode(&struct{}{})
+	assert.ErrorIs(t, err, ErrUnknownMarkupType)
+}
+
+func TestMarkupRegistry_NoUpgradePath(t *testing.T) {
+	r := NewMarkupRegistry(
+		JSONMarkupCodec[MarkupV1](MarkupVersion100),
+		JSONMarkupCodec[markupV0](markupVersion090),
+	)
+
+	_, err := r.DecodeLatest(Markup{Version: markupVersion090, Payload: `{"text":"old"}`})
+	assert.ErrorIs(t, err, ErrNoUpgradePath)
+}
+
+func TestDefaultMarkupRegistry(t *testing.T) {
+	original := &MarkupV1{
+		Message:  "bold",
+		Entities: []markup.Entity{{Type: markup.EntityBold, Offset: 0, Length: 4}},
+	}
+
+	s, err := DefaultMarkupRegistry().EncodeString(original)
+	require.NoError(t, err)
+
+	decoded, err := DefaultMarkupRegistry().DecodeString(s)
+	require.NoError(t, err)
+	assert.Equal(t, original, decoded)
+}
@@ -0,0 +1,108 @@
+package files
+
+import (
+	"fmt"
+
+	"github.com/Justksenia/common/entities/telegram"
+	"github.com/Justksenia/common/entities/telegram/markup"
+)
+
+// Ref is a file referenced by a markup. File points into the markup, so changes of it are visible there.
+type Ref struct {
+	// Path of the file in the markup, e.g. "media[0].thumb".
+	Path string
+	Kind markup.MediaType
+	File *markup.File
+}
+
+// Collect returns every file referenced by the markup, including thumbnails.
+// Custom emojis are references to sticker sets rather than files, so they are skipped.
+func Collect(m *telegram.MarkupV1) []Ref {
+	c := &collector{}
+
+	for i, media := range m.Media {
+		path := fmt.Sprintf("media[%d]", i)
+		switch item := media.(type) {
+		case markup.Photo:
+			c.add(path, markup.MediaTypePhoto, item.File)
+		case *markup.Photo:
+			c.add(path, markup.MediaTypePhoto, item.File)
+		case markup.Video:
+			c.add(path, markup.MediaTypeVideo, item.File)
+			c.thumbnail(path, item.Thumbnail)
+		case *markup.Video:
+			c.add(path, markup.MediaTypeVideo, item.File)
+			c.thumbnail(path, item.Thumbnail)
+		case markup.Animation:
+			c.add(path, markup.MediaTypeAnimation, item.File)
+			c.thumbnail(path, item.Thumbnail)
+		case *markup.Animation:
+			c.add(path, markup.MediaTypeAnimation, item.File)
+			c.thumbnail(path, item.Thumbnail)
+		case markup.Document:
+			c.add(path, markup.MediaTypeDocument, item.File)
+			c.thumbnail(path, item.Thumbnail)
+		case *markup.Document:
+			c.add(path, markup.MediaTypeDocument, item.File)
+			c.thumbnail(path, item.Thumbnail)
+		}
+	}
+	for i, audio := range m.Audios {
+		path := fmt.Sprintf("audios[%d]", i)
+		c.add(path, markup.MediaTypeAudio, audio.File)
+		c.thumbnail(path, audio.Thumbnail)
+	}
+	for i, voice := range m.Voices {
+		c.add(fmt.Sprintf("voices[%d]", i), markup.MediaTypeVoice, voice.File)
+	}
+	for i, note := range m.VideoNotes {
+		path := fmt.Sprintf("video_notes[%d]", i)
+		c.add(path, markup.MediaTypeVideoNote, note.File)
+		c.thumbnail(path, note.Thumbnail)
+	}
+	for i, sticker := range m.Stickers {
+		path := fmt.Sprintf("stickers[%d]", i)
+		c.add(path, markup.MediaTypeSticker, sticker.File)
+		c.thumbnail(path, sticker.Thumbnail)
+	}
+	for i, document := range m.Documents {
+		path := fmt.Sprintf("documents[%d]", i)
+		c.add(path, markup.MediaTypeDocument, document.File)
+		c.thumbnail(path, document.Thumbnail)
+	}
+	return c.refs
+}
+
+type collector struct {
+	refs []Ref
+}
+
+func (c *collector) add(path string, kind markup.MediaType, file *markup.File) {
+	if file == nil {
+		return
+	}
+	c.refs = append(c.refs, Ref{Path: path, Kind: kind, File: file})
+}
+
+func (c *collector) thumbnail(path string, thumb *markup.Photo) {
+	if thumb == nil {
+		return
+	}
+	c.add(path+".thumb", markup.MediaTypePhoto, thumb.File)
+}
+
+// References is a set of S3 paths referenced by stored markups.
+type References map[string]struct{}
+
+func (r References) Add(m *telegram.MarkupV1) {
+	for _, ref := range Collect(m) {
+		if ref.File.S3Path != "" {
+			r[ref.File.S3Path] = struct{}{}
+		}
+	}
+}
+
+func (r References) Contains(path string) bool {
+	_, ok := r[path]
+	return ok
+}
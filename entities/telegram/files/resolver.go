@@ -0,0 +1,143 @@
+package files
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Justksenia/common/entities/telegram"
+	cmnlogger "github.com/Justksenia/common/logger"
+	"github.com/Justksenia/common/s3"
+	"github.com/Justksenia/common/tracer"
+	"github.com/go-faster/errors"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Fetcher downloads the content of a file which isn't stored in S3 yet, e.g. from Telegram by its file id.
+type Fetcher func(ctx context.Context, ref Ref) ([]byte, error)
+
+type Resolver struct {
+	storage s3.Storage
+	fetch   Fetcher
+	prefix  string
+}
+
+// NewResolver creates resolver which uploads missing files under the prefix.
+func NewResolver(storage s3.Storage, fetch Fetcher, prefix string) *Resolver {
+	return &Resolver{
+		storage: storage,
+		fetch:   fetch,
+		prefix:  strings.TrimSuffix(prefix, "/"),
+	}
+}
+
+// Upload fetches files of the markup which have no S3Path, uploads them and fills S3Path in place.
+func (r *Resolver) Upload(ctx context.Context, m *telegram.MarkupV1) error {
+	ctx, span := tracer.StartSpan(ctx, tracer.AutoFillName(), trace.SpanKindInternal)
+	defer span.End()
+
+	for _, ref := range Collect(m) {
+		if ref.File.S3Path != "" {
+			continue
+		}
+		if ref.File.FileID == "" {
+			return span.Error(errors.Errorf("%s: file has neither file id nor s3 path", ref.Path))
+		}
+
+		data, err := r.fetch(ctx, ref)
+		if err != nil {
+			return span.Error(errors.Wrap(err, fmt.Sprintf("%s: fetch file", ref.Path)))
+		}
+
+		key, err := r.storage.Upload(ctx, s3.NewFile(ref.File.FileID, path.Join(r.prefix, strings.ToLower(string(ref.Kind))), data))
+		if err != nil {
+			return span.Error(errors.Wrap(err, fmt.Sprintf("%s: upload file", ref.Path)))
+		}
+		ref.File.S3Path = key
+	}
+	return nil
+}
+
+// ResolvedFile is a file of the markup with URL to download it.
+type ResolvedFile struct {
+	Ref
+	URL string
+}
+
+// Presign returns download URLs valid for ttl for every file of the markup stored in S3.
+func (r *Resolver) Presign(ctx context.Context, m *telegram.MarkupV1, ttl time.Duration) ([]ResolvedFile, error) {
+	ctx, span := tracer.StartSpan(ctx, tracer.AutoFillName(), trace.SpanKindInternal)
+	defer span.End()
+
+	refs := Collect(m)
+	resolved := make([]ResolvedFile, 0, len(refs))
+	for _, ref := range refs {
+		if ref.File.S3Path == "" {
+			continue
+		}
+		url, err := r.storage.PresignGet(ctx, ref.File.S3Path, ttl)
+		if err != nil {
+			return nil, span.Error(errors.Wrap(err, fmt.Sprintf("%s: presign", ref.Path)))
+		}
+		resolved = append(resolved, ResolvedFile{Ref: ref, URL: url})
+	}
+	return resolved, nil
+}
+
+// defaultMinAge protects files uploaded for markups which aren't persisted yet.
+const defaultMinAge = 24 * time.Hour
+
+type GCOption func(o *gcOptions)
+
+type gcOptions struct {
+	minAge time.Duration
+}
+
+// WithMinAge sets how old unreferenced objects must be to be deleted, 24 hours by default.
+func WithMinAge(d time.Duration) GCOption {
+	return func(o *gcOptions) {
+		o.minAge = d
+	}
+}
+
+// CollectGarbage deletes objects under the resolver prefix which aren't in referenced and are older than
+// the min age. It returns paths of deleted objects.
+func (r *Resolver) CollectGarbage(ctx context.Context, referenced References, opts ...GCOption) ([]string, error) {
+	ctx, span := tracer.StartSpan(ctx, tracer.AutoFillName(), trace.SpanKindInternal)
+	defer span.End()
+
+	options := gcOptions{minAge: defaultMinAge}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	threshold := time.Now().Add(-options.minAge)
+
+	logger := cmnlogger.FromContext(ctx).With(zap.String("Method", tracer.AutoFillName()))
+
+	var (
+		deleted []string
+		total   int
+	)
+	it := r.storage.List(r.prefix + "/")
+	for it.Next(ctx) {
+		total++
+		key := it.Object().Key
+		if referenced.Contains(key) || it.Object().LastModified.After(threshold) {
+			continue
+		}
+		if err := r.storage.Delete(ctx, key); err != nil {
+			logger.Error("failed to delete unreferenced object", zap.String("path", key), zap.Error(err))
+			continue
+		}
+		deleted = append(deleted, key)
+	}
+	if err := it.Err(); err != nil {
+		return deleted, span.Error(errors.Wrap(err, "list objects"))
+	}
+
+	logger.Info("garbage collected", zap.Int("deleted", len(deleted)), zap.Int("total", total))
+	return deleted, nil
+}
@@ -0,0 +1,119 @@
+package files
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Justksenia/common/entities/telegram"
+	"github.com/Justksenia/common/entities/telegram/markup"
+	"github.com/Justksenia/common/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func keys(t *testing.T, storage s3.Storage) []string {
+	t.Helper()
+	var keys []string
+	it := storage.List("")
+	for it.Next(context.Background()) {
+		keys = append(keys, it.Object().Key)
+	}
+	require.NoError(t, it.Err())
+	return keys
+}
+
+func file(kind markup.MediaType, id string) *markup.File {
+	return &markup.File{Type: kind, FileID: id}
+}
+
+func testMarkup() *telegram.MarkupV1 {
+	return &telegram.MarkupV1{
+		Media: markup.Album{
+			markup.Photo{File: file(markup.MediaTypePhoto, "photo")},
+			markup.Video{
+				File:      file(markup.MediaTypeVideo, "video"),
+				Thumbnail: &markup.Photo{File: file(markup.MediaTypePhoto, "thumb")},
+			},
+		},
+		Voices: []markup.Voice{{File: file(markup.MediaTypeVoice, "voice")}},
+		Documents: []markup.Document{{
+			File: &markup.File{Type: markup.MediaTypeDocument, FileID: "document", S3Path: "files/document/document"},
+		}},
+	}
+}
+
+func TestCollect(t *testing.T) {
+	refs := Collect(testMarkup())
+
+	paths := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		paths = append(paths, ref.Path+":"+string(ref.Kind))
+	}
+	assert.Equal(t, []string{
+		"media[0]:Photo",
+		"media[1]:Video",
+		"media[1].thumb:Photo",
+		"voices[0]:Voice",
+		"documents[0]:Document",
+	}, paths)
+}
+
+func TestResolver(t *testing.T) {
+	ctx := context.Background()
+	storage := s3.NewMemoryStorage()
+	for _, f := range []*s3.File{
+		s3.NewFile("document", "files/document", []byte("document")),
+		s3.NewFile("orphan", "files/photo", []byte("orphan")),
+	} {
+		_, err := storage.Upload(ctx, f)
+		require.NoError(t, err)
+	}
+
+	var fetched []string
+	resolver := NewResolver(storage, func(_ context.Context, ref Ref) ([]byte, error) {
+		fetched = append(fetched, ref.File.FileID)
+		return []byte(ref.File.FileID), nil
+	}, "files/")
+
+	m := testMarkup()
+	require.NoError(t, resolver.Upload(ctx, m))
+	assert.Equal(t, []string{"photo", "video", "thumb", "voice"}, fetched)
+	assert.Equal(t, "files/video/video", m.Media[1].(markup.Video).File.S3Path)
+	assert.Equal(t, "files/photo/thumb", m.Media[1].(markup.Video).Thumbnail.File.S3Path)
+	voice, err := storage.Get(ctx, "files/voice/voice")
+	require.NoError(t, err)
+	data, err := voice.Data()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("voice"), data)
+
+	resolved, err := resolver.Presign(ctx, m, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, resolved, 5)
+	assert.True(t, strings.HasPrefix(resolved[0].URL, "memory://files/photo/photo?"), resolved[0].URL)
+
+	references := References{}
+	references.Add(m)
+	// the orphan may belong to a markup which isn't persisted yet
+	deleted, err := resolver.CollectGarbage(ctx, references)
+	require.NoError(t, err)
+	assert.Empty(t, deleted)
+
+	deleted, err = resolver.CollectGarbage(ctx, references, WithMinAge(0))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"files/photo/orphan"}, deleted)
+	assert.Equal(t, []string{
+		"files/document/document",
+		"files/photo/photo",
+		"files/photo/thumb",
+		"files/video/video",
+		"files/voice/voice",
+	}, keys(t, storage))
+}
+
+func TestResolver_UploadWithoutFileID(t *testing.T) {
+	resolver := NewResolver(s3.NewMemoryStorage(), nil, "files")
+	m := &telegram.MarkupV1{Voices: []markup.Voice{{File: &markup.File{Type: markup.MediaTypeVoice}}}}
+	assert.Error(t, resolver.Upload(context.Background(), m))
+}
@@ -0,0 +1,124 @@
+package markup
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-faster/errors"
+)
+
+var (
+	ErrMissingMediaType  = errors.New("missing media type")
+	ErrUnknownMediaType  = errors.New("unknown media type")
+	ErrMismatchMediaType = errors.New("media type doesn't match media")
+)
+
+// Album is a group of media sent as a single message. Items are (un)marshalled by File.Type.
+type Album []PhotoOrVideo
+
+func (a Album) MarshalJSON() ([]byte, error) {
+	items := make([]json.RawMessage, 0, len(a))
+	for i, item := range a {
+		data, err := MarshalMedia(item)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("media[%d]", i))
+		}
+		items = append(items, data)
+	}
+	return json.Marshal(items)
+}
+
+func (a *Album) UnmarshalJSON(data []byte) error {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	if items == nil {
+		*a = nil
+		return nil
+	}
+
+	album := make(Album, 0, len(items))
+	for i, item := range items {
+		media, err := UnmarshalMedia(item)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("media[%d]", i))
+		}
+		album = append(album, media)
+	}
+	*a = album
+	return nil
+}
+
+// MarshalMedia marshals media of an album, checking that File.Type matches the concrete media.
+func MarshalMedia(media PhotoOrVideo) ([]byte, error) {
+	expected, file, err := mediaFile(media)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil || file.Type == "" {
+		return nil, ErrMissingMediaType
+	}
+	if file.Type != expected {
+		return nil, errors.Wrap(ErrMismatchMediaType, fmt.Sprintf("%s for %T", file.Type, media))
+	}
+	return json.Marshal(media)
+}
+
+// UnmarshalMedia unmarshals media of an album into the type set in its "type" field.
+func UnmarshalMedia(data []byte) (PhotoOrVideo, error) {
+	var discriminator struct {
+		Type MediaType `json:"type"`
+	}
+	if err := json.Unmarshal(data, &discriminator); err != nil {
+		return nil, err
+	}
+
+	switch discriminator.Type {
+	case MediaTypePhoto:
+		return unmarshalMedia[Photo](data)
+	case MediaTypeVideo:
+		return unmarshalMedia[Video](data)
+	case MediaTypeAnimation:
+		return unmarshalMedia[Animation](data)
+	case MediaTypeDocument:
+		return unmarshalMedia[Document](data)
+	case "":
+		return nil, ErrMissingMediaType
+	case MediaTypeAudio, MediaTypeVoice, MediaTypeVideoNote, MediaTypeSticker, MediaTypeDice:
+		return nil, errors.Wrap(ErrUnknownMediaType, fmt.Sprintf("%s can't be a part of an album", discriminator.Type))
+	default:
+		return nil, errors.Wrap(ErrUnknownMediaType, string(discriminator.Type))
+	}
+}
+
+func unmarshalMedia[T PhotoOrVideo](data []byte) (PhotoOrVideo, error) {
+	var media T
+	if err := json.Unmarshal(data, &media); err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+func mediaFile(media PhotoOrVideo) (MediaType, *File, error) {
+	switch m := media.(type) {
+	case Photo:
+		return MediaTypePhoto, m.File, nil
+	case *Photo:
+		return MediaTypePhoto, m.File, nil
+	case Video:
+		return MediaTypeVideo, m.File, nil
+	case *Video:
+		return MediaTypeVideo, m.File, nil
+	case Animation:
+		return MediaTypeAnimation, m.File, nil
+	case *Animation:
+		return MediaTypeAnimation, m.File, nil
+	case Document:
+		return MediaTypeDocument, m.File, nil
+	case *Document:
+		return MediaTypeDocument, m.File, nil
+	default:
+		return "", nil, errors.Wrap(ErrUnknownMediaType, fmt.Sprintf("%T", media))
+	}
+}
@@ -0,0 +1,161 @@
+package markup
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:gochecknoglobals // test flag
+var update = flag.Bool("update", false, "update golden files")
+
+func file(t MediaType, id string) *File {
+	return &File{Type: t, FileID: id, S3Path: "creatives/" + id}
+}
+
+func thumbnail() *Photo {
+	return &Photo{File: file(MediaTypePhoto, "thumb"), Width: 320, Height: 180}
+}
+
+func TestMediaGolden(t *testing.T) {
+	testCases := []struct {
+		name  string
+		media any
+	}{
+		{
+			name:  "photo",
+			media: Photo{File: file(MediaTypePhoto, "photo"), Width: 1280, Height: 720, Caption: "photo"},
+		},
+		{
+			name: "video",
+			media: Video{
+				File: file(MediaTypeVideo, "video"), Width: 1920, Height: 1080, Duration: 30,
+				Caption: "video", Thumbnail: thumbnail(), MIMEType: "video/mp4", FileName: "video.mp4",
+			},
+		},
+		{
+			name: "animation",
+			media: Animation{
+				File: file(MediaTypeAnimation, "animation"), Width: 480, Height: 270, Duration: 3,
+				Thumbnail: thumbnail(), MIMEType: "video/mp4", FileName: "animation.mp4",
+			},
+		},
+		{
+			name: "document",
+			media: Document{
+				File: file(MediaTypeDocument, "document"), Thumbnail: thumbnail(), Caption: "document",
+				MIME: "application/pdf", FileName: "contract.pdf", DisableTypeDetection: true,
+			},
+		},
+		{
+			name: "audio",
+			media: Audio{
+				File: file(MediaTypeAudio, "audio"), Duration: 180, Caption: "audio", Thumbnail: thumbnail(),
+				Title: "title", Performer: "performer", MIME: "audio/mpeg", FileName: "audio.mp3",
+			},
+		},
+		{
+			name:  "voice",
+			media: Voice{File: file(MediaTypeVoice, "voice"), Duration: 5, Caption: "voice", MIMEType: "audio/ogg"},
+		},
+		{
+			name:  "video_note",
+			media: VideoNote{File: file(MediaTypeVideoNote, "video_note"), Duration: 10, Thumbnail: thumbnail(), Length: 240},
+		},
+		{
+			name: "sticker",
+			media: Sticker{
+				File: file(MediaTypeSticker, "sticker"), Width: 512, Height: 512, IsAnimated: true,
+				Thumbnail: thumbnail(), Emoji: "👍", SetName: "set",
+				MaskPosition: &MaskPosition{Point: "eyes", XShift: 0.5, YShift: -0.5, Scale: 2},
+			},
+		},
+		{
+			name:  "dice",
+			media: Dice{Type: Cube.Type, Value: 6},
+		},
+		{
+			name: "album",
+			media: Album{
+				Photo{File: file(MediaTypePhoto, "photo"), Width: 1280, Height: 720},
+				Video{File: file(MediaTypeVideo, "video"), Width: 1920, Height: 1080, Duration: 30},
+				Animation{File: file(MediaTypeAnimation, "animation"), Width: 480, Height: 270, Duration: 3},
+				Document{File: file(MediaTypeDocument, "document"), MIME: "application/pdf"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := json.MarshalIndent(tc.media, "", "  ")
+			require.NoError(t, err)
+
+			golden := filepath.Join("testdata", tc.name+".json")
+			if *update {
+				require.NoError(t, os.WriteFile(golden, append(data, '\n'), 0o600))
+			}
+			expected, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(expected), string(data))
+
+			decoded := reflect.New(reflect.TypeOf(tc.media))
+			require.NoError(t, json.Unmarshal(expected, decoded.Interface()))
+			assert.Equal(t, tc.media, decoded.Elem().Interface())
+		})
+	}
+}
+
+func TestUnmarshalMedia(t *testing.T) {
+	testCases := []struct {
+		name        string
+		data        string
+		expected    PhotoOrVideo
+		expectedErr error
+	}{
+		{
+			name:     "photo",
+			data:     `{"type":"Photo","file_id":"id","s3_file_path":"","width":1,"height":2}`,
+			expected: Photo{File: &File{Type: MediaTypePhoto, FileID: "id"}, Width: 1, Height: 2},
+		},
+		{
+			name:        "missing type",
+			data:        `{"file_id":"id"}`,
+			expectedErr: ErrMissingMediaType,
+		},
+		{
+			name:        "audio isn't album media",
+			data:        `{"type":"Audio","file_id":"id"}`,
+			expectedErr: ErrUnknownMediaType,
+		},
+		{
+			name:        "unknown type",
+			data:        `{"type":"Hologram","file_id":"id"}`,
+			expectedErr: ErrUnknownMediaType,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			media, err := UnmarshalMedia([]byte(tc.data))
+			assert.ErrorIs(t, err, tc.expectedErr)
+			assert.Equal(t, tc.expected, media)
+		})
+	}
+}
+
+func TestMarshalMedia(t *testing.T) {
+	_, err := MarshalMedia(Photo{})
+	assert.ErrorIs(t, err, ErrMissingMediaType)
+
+	_, err = MarshalMedia(Photo{File: &File{Type: MediaTypeVideo}})
+	assert.ErrorIs(t, err, ErrMismatchMediaType)
+
+	_, err = json.Marshal(Album{Video{File: &File{Type: MediaTypePhoto}}})
+	assert.ErrorIs(t, err, ErrMismatchMediaType)
+}
@@ -0,0 +1,252 @@
+package markup
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-faster/errors"
+)
+
+var ErrInvalidHTML = errors.New("invalid html markup")
+
+// RenderHTML renders text with entities using Telegram HTML style.
+func RenderHTML(text string, entities Entities) string {
+	return render(text, entities, &htmlFormatter{})
+}
+
+type htmlFormatter struct {
+	b strings.Builder
+}
+
+func (f *htmlFormatter) quoteLine() {}
+
+func (f *htmlFormatter) open(e Entity) {
+	switch e.Type { //nolint:exhaustive // only renderable entities get here
+	case EntityBold:
+		f.b.WriteString("<b>")
+	case EntityItalic:
+		f.b.WriteString("<i>")
+	case EntityUnderline:
+		f.b.WriteString("<u>")
+	case EntityStrikethrough:
+		f.b.WriteString("<s>")
+	case EntitySpoiler:
+		f.b.WriteString("<tg-spoiler>")
+	case EntityCode:
+		f.b.WriteString("<code>")
+	case EntityCodeBlock:
+		f.b.WriteString("<pre>")
+		if e.Language != "" {
+			fmt.Fprintf(&f.b, `<code class="language-%s">`, html.EscapeString(e.Language))
+		}
+	case EntityTextLink, EntityTMention:
+		fmt.Fprintf(&f.b, `<a href="%s">`, html.EscapeString(e.URL))
+	case EntityCustomEmoji:
+		fmt.Fprintf(&f.b, `<tg-emoji emoji-id="%s">`, html.EscapeString(e.customEmojiID()))
+	case EntityBlockquote:
+		f.b.WriteString("<blockquote>")
+	}
+}
+
+func (f *htmlFormatter) close(e Entity) {
+	switch e.Type { //nolint:exhaustive // only renderable entities get here
+	case EntityBold:
+		f.b.WriteString("</b>")
+	case EntityItalic:
+		f.b.WriteString("</i>")
+	case EntityUnderline:
+		f.b.WriteString("</u>")
+	case EntityStrikethrough:
+		f.b.WriteString("</s>")
+	case EntitySpoiler:
+		f.b.WriteString("</tg-spoiler>")
+	case EntityCode:
+		f.b.WriteString("</code>")
+	case EntityCodeBlock:
+		if e.Language != "" {
+			f.b.WriteString("</code>")
+		}
+		f.b.WriteString("</pre>")
+	case EntityTextLink, EntityTMention:
+		f.b.WriteString("</a>")
+	case EntityCustomEmoji:
+		f.b.WriteString("</tg-emoji>")
+	case EntityBlockquote:
+		f.b.WriteString("</blockquote>")
+	}
+}
+
+func (f *htmlFormatter) text(s string, _ bool) {
+	f.b.WriteString(html.EscapeString(s))
+}
+
+func (f *htmlFormatter) String() string {
+	return f.b.String()
+}
+
+var htmlAttrRegexp = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9-]*)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+
+type htmlTag struct {
+	name   string
+	entity *Entity
+}
+
+// ParseHTML parses Telegram HTML style markup into plain text and entities.
+func ParseHTML(s string) (string, Entities, error) {
+	var (
+		text     textBuilder
+		stack    []htmlTag
+		entities Entities
+	)
+
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '<':
+			end := strings.IndexByte(s[i:], '>')
+			if end == -1 {
+				return "", nil, errors.Wrap(ErrInvalidHTML, fmt.Sprintf("unclosed tag at %d", i))
+			}
+			tag := s[i+1 : i+end]
+			i += end + 1
+
+			if strings.HasPrefix(tag, "/") {
+				name := strings.ToLower(strings.TrimSpace(tag[1:]))
+				if len(stack) == 0 || stack[len(stack)-1].name != name {
+					return "", nil, errors.Wrap(ErrInvalidHTML, fmt.Sprintf("unexpected closing tag %q", name))
+				}
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				if top.entity != nil {
+					top.entity.Length = text.pos - top.entity.Offset
+					entities = append(entities, *top.entity)
+				}
+				continue
+			}
+
+			opened, err := openHTMLTag(tag, text.pos, stack)
+			if err != nil {
+				return "", nil, err
+			}
+			stack = append(stack, opened)
+		case '&':
+			end := strings.IndexByte(s[i:], ';')
+			if end == -1 {
+				return "", nil, errors.Wrap(ErrInvalidHTML, fmt.Sprintf("unterminated entity at %d", i))
+			}
+			r, err := unescapeHTMLEntity(s[i+1 : i+end])
+			if err != nil {
+				return "", nil, err
+			}
+			text.writeRune(r)
+			i += end + 1
+		default:
+			next := strings.IndexAny(s[i:], "<&")
+			if next == -1 {
+				next = len(s) - i
+			}
+			text.writeString(s[i : i+next])
+			i += next
+		}
+	}
+
+	if len(stack) > 0 {
+		return "", nil, errors.Wrap(ErrInvalidHTML, fmt.Sprintf("unclosed tag %q", stack[len(stack)-1].name))
+	}
+	return text.String(), entities.Normalize(), nil
+}
+
+func openHTMLTag(tag string, pos int, stack []htmlTag) (htmlTag, error) {
+	tag = strings.TrimSpace(tag)
+	name := tag
+	if idx := strings.IndexAny(tag, " \t\n"); idx != -1 {
+		name = tag[:idx]
+	}
+	name = strings.ToLower(name)
+
+	attrs := make(map[string]string)
+	for _, m := range htmlAttrRegexp.FindAllStringSubmatch(tag[len(name):], -1) {
+		attrs[strings.ToLower(m[1])] = html.UnescapeString(m[2] + m[3])
+	}
+
+	entity := &Entity{Offset: pos}
+	switch name {
+	case "b", "strong":
+		entity.Type = EntityBold
+	case "i", "em":
+		entity.Type = EntityItalic
+	case "u", "ins":
+		entity.Type = EntityUnderline
+	case "s", "strike", "del":
+		entity.Type = EntityStrikethrough
+	case "tg-spoiler":
+		entity.Type = EntitySpoiler
+	case "span":
+		if attrs["class"] != "tg-spoiler" {
+			return htmlTag{}, errors.Wrap(ErrInvalidHTML, "span tag without tg-spoiler class")
+		}
+		entity.Type = EntitySpoiler
+	case "code":
+		// <pre><code class="language-x"> sets the language of the code block.
+		if len(stack) > 0 {
+			top := stack[len(stack)-1]
+			if top.name == "pre" && top.entity.Offset == pos && top.entity.Language == "" {
+				top.entity.Language = strings.TrimPrefix(attrs["class"], "language-")
+				return htmlTag{name: name}, nil
+			}
+		}
+		entity.Type = EntityCode
+	case "pre":
+		entity.Type = EntityCodeBlock
+	case "a":
+		href, ok := attrs["href"]
+		if !ok {
+			return htmlTag{}, errors.Wrap(ErrInvalidHTML, "a tag without href")
+		}
+		link := linkEntity(href)
+		link.Offset = pos
+		entity = &link
+	case "tg-emoji":
+		id, ok := attrs["emoji-id"]
+		if !ok {
+			return htmlTag{}, errors.Wrap(ErrInvalidHTML, "tg-emoji tag without emoji-id")
+		}
+		emoji := customEmojiEntity(id)
+		emoji.Offset = pos
+		entity = &emoji
+	case "blockquote":
+		entity.Type = EntityBlockquote
+	default:
+		return htmlTag{}, errors.Wrap(ErrInvalidHTML, fmt.Sprintf("unsupported tag %q", name))
+	}
+	return htmlTag{name: name, entity: entity}, nil
+}
+
+func unescapeHTMLEntity(name string) (rune, error) {
+	switch name {
+	case "lt":
+		return '<', nil
+	case "gt":
+		return '>', nil
+	case "amp":
+		return '&', nil
+	case "quot":
+		return '"', nil
+	case "#39", "apos":
+		return '\'', nil
+	}
+
+	if strings.HasPrefix(name, "#") {
+		base, digits := 10, name[1:]
+		if strings.HasPrefix(digits, "x") || strings.HasPrefix(digits, "X") {
+			base, digits = 16, digits[1:]
+		}
+		code, err := strconv.ParseInt(digits, base, 32)
+		if err == nil {
+			return rune(code), nil
+		}
+	}
+	return 0, errors.Wrap(ErrInvalidHTML, fmt.Sprintf("unsupported entity &%s;", name))
+}
@@ -0,0 +1,213 @@
+package markup
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/go-faster/errors"
+)
+
+// Limits of the Telegram Bot API for keyboards.
+const (
+	MaxCallbackDataSize      = 64
+	MaxInlineKeyboardButtons = 100
+	MaxInlineRowButtons      = 8
+	MaxReplyKeyboardButtons  = 300
+	MaxReplyRowButtons       = 12
+)
+
+var (
+	ErrKeyboardSize         = errors.Wrap(ErrInvalidMarkup, "too many buttons")
+	ErrButtonAction         = errors.Wrap(ErrInvalidMarkup, "button must have exactly one action")
+	ErrInvalidURL           = errors.Wrap(ErrInvalidMarkup, "invalid url")
+	ErrAmbiguousReplyMarkup = errors.Wrap(ErrInvalidMarkup, "only one keyboard can be set")
+)
+
+// ReplyMarkup is a keyboard attached to the message. Only one of keyboards is set,
+// the JSON is compatible with reply_markup of the Bot API.
+type ReplyMarkup struct {
+	*InlineKeyboard
+	*ReplyKeyboard
+}
+
+type InlineKeyboard struct {
+	Rows [][]InlineButton `json:"inline_keyboard"`
+}
+
+type InlineButton struct {
+	Text string `json:"text"`
+
+	// Exactly one of the fields below must be set.
+	URL                          string      `json:"url,omitempty"`
+	CallbackData                 string      `json:"callback_data,omitempty"`
+	SwitchInlineQuery            *string     `json:"switch_inline_query,omitempty"`
+	SwitchInlineQueryCurrentChat *string     `json:"switch_inline_query_current_chat,omitempty"`
+	LoginURL                     *LoginURL   `json:"login_url,omitempty"`
+	WebApp                       *WebAppInfo `json:"web_app,omitempty"`
+}
+
+type LoginURL struct {
+	URL                string `json:"url"`
+	ForwardText        string `json:"forward_text,omitempty"`
+	BotUsername        string `json:"bot_username,omitempty"`
+	RequestWriteAccess bool   `json:"request_write_access,omitempty"`
+}
+
+type WebAppInfo struct {
+	URL string `json:"url"`
+}
+
+type ReplyKeyboard struct {
+	Rows                  [][]KeyboardButton `json:"keyboard"`
+	IsPersistent          bool               `json:"is_persistent,omitempty"`
+	ResizeKeyboard        bool               `json:"resize_keyboard,omitempty"`
+	OneTimeKeyboard       bool               `json:"one_time_keyboard,omitempty"`
+	InputFieldPlaceholder string             `json:"input_field_placeholder,omitempty"`
+	Selective             bool               `json:"selective,omitempty"`
+}
+
+type KeyboardButton struct {
+	Text string `json:"text"`
+
+	// (Optional) At most one of the fields below can be set.
+	RequestContact  bool                    `json:"request_contact,omitempty"`
+	RequestLocation bool                    `json:"request_location,omitempty"`
+	RequestPoll     *KeyboardButtonPollType `json:"request_poll,omitempty"`
+	WebApp          *WebAppInfo             `json:"web_app,omitempty"`
+}
+
+type KeyboardButtonPollType struct {
+	Type PollType `json:"type,omitempty"`
+}
+
+func NewInlineKeyboard(rows ...[]InlineButton) *ReplyMarkup {
+	return &ReplyMarkup{InlineKeyboard: &InlineKeyboard{Rows: rows}}
+}
+
+func NewReplyKeyboard(rows ...[]KeyboardButton) *ReplyMarkup {
+	return &ReplyMarkup{ReplyKeyboard: &ReplyKeyboard{Rows: rows}}
+}
+
+func URLButton(text, url string) InlineButton {
+	return InlineButton{Text: text, URL: url}
+}
+
+func CallbackButton(text, data string) InlineButton {
+	return InlineButton{Text: text, CallbackData: data}
+}
+
+func WebAppButton(text, url string) InlineButton {
+	return InlineButton{Text: text, WebApp: &WebAppInfo{URL: url}}
+}
+
+func (m *ReplyMarkup) Validate(v *Validator, path string) {
+	switch {
+	case m.InlineKeyboard != nil && m.ReplyKeyboard != nil:
+		v.Add(path, ErrAmbiguousReplyMarkup)
+	case m.InlineKeyboard != nil:
+		m.InlineKeyboard.Validate(v, joinPath(path, "inline_keyboard"))
+	case m.ReplyKeyboard != nil:
+		m.ReplyKeyboard.Validate(v, joinPath(path, "keyboard"))
+	}
+}
+
+func (k *InlineKeyboard) Validate(v *Validator, path string) {
+	validateKeyboardSize(v, path, len(k.Rows), func(i int) int { return len(k.Rows[i]) },
+		MaxInlineKeyboardButtons, MaxInlineRowButtons)
+
+	for i, row := range k.Rows {
+		for j, button := range row {
+			button.validate(v, joinPath(path, fmt.Sprintf("[%d][%d]", i, j)))
+		}
+	}
+}
+
+func (b InlineButton) validate(v *Validator, path string) {
+	if b.Text == "" {
+		v.Add(joinPath(path, "text"), ErrEmptyValue)
+	}
+
+	actions := 0
+	for _, set := range []bool{
+		b.URL != "", b.CallbackData != "", b.SwitchInlineQuery != nil,
+		b.SwitchInlineQueryCurrentChat != nil, b.LoginURL != nil, b.WebApp != nil,
+	} {
+		if set {
+			actions++
+		}
+	}
+	if actions != 1 {
+		v.Addf(path, ErrButtonAction, "%d actions", actions)
+	}
+
+	if b.URL != "" {
+		validateURL(v, joinPath(path, "url"), b.URL, "http", "https", "tg")
+	}
+	if size := len(b.CallbackData); size > MaxCallbackDataSize {
+		v.Addf(joinPath(path, "callback_data"), ErrTooLong, "%d of %d bytes", size, MaxCallbackDataSize)
+	}
+	if b.LoginURL != nil {
+		validateURL(v, joinPath(path, "login_url.url"), b.LoginURL.URL, "https")
+	}
+	if b.WebApp != nil {
+		validateURL(v, joinPath(path, "web_app.url"), b.WebApp.URL, "https")
+	}
+}
+
+func (k *ReplyKeyboard) Validate(v *Validator, path string) {
+	validateKeyboardSize(v, path, len(k.Rows), func(i int) int { return len(k.Rows[i]) },
+		MaxReplyKeyboardButtons, MaxReplyRowButtons)
+
+	for i, row := range k.Rows {
+		for j, button := range row {
+			button.validate(v, joinPath(path, fmt.Sprintf("[%d][%d]", i, j)))
+		}
+	}
+}
+
+func (b KeyboardButton) validate(v *Validator, path string) {
+	if b.Text == "" {
+		v.Add(joinPath(path, "text"), ErrEmptyValue)
+	}
+
+	actions := 0
+	for _, set := range []bool{b.RequestContact, b.RequestLocation, b.RequestPoll != nil, b.WebApp != nil} {
+		if set {
+			actions++
+		}
+	}
+	if actions > 1 {
+		v.Addf(path, ErrButtonAction, "%d actions", actions)
+	}
+
+	if b.WebApp != nil {
+		validateURL(v, joinPath(path, "web_app.url"), b.WebApp.URL, "https")
+	}
+}
+
+func validateKeyboardSize(v *Validator, path string, rows int, rowSize func(i int) int, maxButtons, maxRowButtons int) {
+	total := 0
+	for i := 0; i < rows; i++ {
+		if size := rowSize(i); size > maxRowButtons {
+			v.Addf(joinPath(path, fmt.Sprintf("[%d]", i)), ErrKeyboardSize, "%d of %d in a row", size, maxRowButtons)
+		}
+		total += rowSize(i)
+	}
+	if total > maxButtons {
+		v.Addf(path, ErrKeyboardSize, "%d of %d", total, maxButtons)
+	}
+}
+
+func validateURL(v *Validator, path, rawURL string, schemes ...string) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		v.Addf(path, ErrInvalidURL, "%q", rawURL)
+		return
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return
+		}
+	}
+	v.Addf(path, ErrInvalidURL, "scheme of %q, expected one of %v", rawURL, schemes)
+}
@@ -0,0 +1,130 @@
+package markup
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplyMarkup_JSON(t *testing.T) {
+	testCases := []struct {
+		name     string
+		markup   *ReplyMarkup
+		expected string
+	}{
+		{
+			name: "inline keyboard",
+			markup: NewInlineKeyboard(
+				[]InlineButton{URLButton("Open", "https://t.me/channel"), CallbackButton("Like", "like:1")},
+				[]InlineButton{WebAppButton("App", "https://app.example.com")},
+			),
+			expected: `{"inline_keyboard":[
+				[{"text":"Open","url":"https://t.me/channel"},{"text":"Like","callback_data":"like:1"}],
+				[{"text":"App","web_app":{"url":"https://app.example.com"}}]
+			]}`,
+		},
+		{
+			name: "reply keyboard",
+			markup: &ReplyMarkup{ReplyKeyboard: &ReplyKeyboard{
+				Rows:           [][]KeyboardButton{{{Text: "Contact", RequestContact: true}}},
+				ResizeKeyboard: true,
+			}},
+			expected: `{"keyboard":[[{"text":"Contact","request_contact":true}]],"resize_keyboard":true}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := json.Marshal(tc.markup)
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(data))
+
+			decoded := &ReplyMarkup{}
+			require.NoError(t, json.Unmarshal(data, decoded))
+			assert.Equal(t, tc.markup, decoded)
+		})
+	}
+}
+
+func TestReplyMarkup_Validate(t *testing.T) {
+	emptyQuery := ""
+	row := func(n int) []InlineButton {
+		buttons := make([]InlineButton, 0, n)
+		for i := 0; i < n; i++ {
+			buttons = append(buttons, CallbackButton("b", "data"))
+		}
+		return buttons
+	}
+
+	testCases := []struct {
+		name         string
+		markup       *ReplyMarkup
+		expectedErr  error
+		expectedPath string
+	}{
+		{
+			name:   "valid inline keyboard",
+			markup: NewInlineKeyboard([]InlineButton{{Text: "Share", SwitchInlineQuery: &emptyQuery}}),
+		},
+		{
+			name:         "callback data too long",
+			markup:       NewInlineKeyboard([]InlineButton{CallbackButton("b", strings.Repeat("a", MaxCallbackDataSize+1))}),
+			expectedErr:  ErrTooLong,
+			expectedPath: "reply_markup.inline_keyboard[0][0].callback_data",
+		},
+		{
+			name:         "too many buttons in a row",
+			markup:       NewInlineKeyboard(row(MaxInlineRowButtons + 1)),
+			expectedErr:  ErrKeyboardSize,
+			expectedPath: "reply_markup.inline_keyboard[0]",
+		},
+		{
+			name:         "button without action",
+			markup:       NewInlineKeyboard([]InlineButton{{Text: "b"}}),
+			expectedErr:  ErrButtonAction,
+			expectedPath: "reply_markup.inline_keyboard[0][0]",
+		},
+		{
+			name:         "invalid url scheme",
+			markup:       NewInlineKeyboard([]InlineButton{URLButton("b", "ftp://example.com")}),
+			expectedErr:  ErrInvalidURL,
+			expectedPath: "reply_markup.inline_keyboard[0][0].url",
+		},
+		{
+			name: "both keyboards",
+			markup: &ReplyMarkup{
+				InlineKeyboard: &InlineKeyboard{},
+				ReplyKeyboard:  &ReplyKeyboard{},
+			},
+			expectedErr:  ErrAmbiguousReplyMarkup,
+			expectedPath: "reply_markup",
+		},
+		{
+			name: "reply button with several requests",
+			markup: NewReplyKeyboard([]KeyboardButton{
+				{Text: "b", RequestContact: true, RequestLocation: true},
+			}),
+			expectedErr:  ErrButtonAction,
+			expectedPath: "reply_markup.keyboard[0][0]",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := &Validator{}
+			tc.markup.Validate(v, "reply_markup")
+			err := v.Err()
+
+			assert.ErrorIs(t, err, tc.expectedErr)
+			if tc.expectedErr == nil {
+				return
+			}
+			var fieldErr *FieldError
+			require.ErrorAs(t, err, &fieldErr)
+			assert.Equal(t, tc.expectedPath, fieldErr.Path)
+		})
+	}
+}
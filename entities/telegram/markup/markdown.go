@@ -0,0 +1,354 @@
+package markup
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-faster/errors"
+)
+
+var ErrInvalidMarkdown = errors.New("invalid markdown markup")
+
+const (
+	markdownSpecialChars = "_*[]()~`>#+-=|{}.!\\"
+	markdownCodeChars    = "`\\"
+	markdownURLChars     = ")\\"
+)
+
+// RenderMarkdownV2 renders text with entities using Telegram MarkdownV2 style.
+// Blockquotes are expressed with line prefixes, so they should cover whole lines.
+func RenderMarkdownV2(text string, entities Entities) string {
+	return render(text, entities, &markdownFormatter{})
+}
+
+type markdownFormatter struct {
+	b strings.Builder
+	// underscore is set when the last written markup ends with '_'. Telegram treats "__" greedily
+	// as underline, so consecutive italic and underline markers are separated with '\r', which is ignored.
+	underscore bool
+}
+
+func (f *markdownFormatter) marker(s string) {
+	if f.underscore && strings.HasPrefix(s, "_") {
+		f.b.WriteByte('\r')
+	}
+	f.b.WriteString(s)
+	f.underscore = strings.HasSuffix(s, "_")
+}
+
+func (f *markdownFormatter) quoteLine() {
+	f.marker(">")
+}
+
+func (f *markdownFormatter) open(e Entity) {
+	switch e.Type { //nolint:exhaustive // only renderable entities get here
+	case EntityBold:
+		f.marker("*")
+	case EntityItalic:
+		f.marker("_")
+	case EntityUnderline:
+		f.marker("__")
+	case EntityStrikethrough:
+		f.marker("~")
+	case EntitySpoiler:
+		f.marker("||")
+	case EntityCode:
+		f.marker("`")
+	case EntityCodeBlock:
+		f.marker("```" + e.Language + "\n")
+	case EntityTextLink, EntityTMention:
+		f.marker("[")
+	case EntityCustomEmoji:
+		f.marker("![")
+	case EntityBlockquote:
+		// lines of a blockquote are prefixed with '>' in quoteLine
+	}
+}
+
+func (f *markdownFormatter) close(e Entity) {
+	switch e.Type { //nolint:exhaustive // only renderable entities get here
+	case EntityBold:
+		f.marker("*")
+	case EntityItalic:
+		f.marker("_")
+	case EntityUnderline:
+		f.marker("__")
+	case EntityStrikethrough:
+		f.marker("~")
+	case EntitySpoiler:
+		f.marker("||")
+	case EntityCode:
+		f.marker("`")
+	case EntityCodeBlock:
+		f.marker("```")
+	case EntityTextLink, EntityTMention:
+		f.marker("](" + escapeMarkdown(e.URL, markdownURLChars) + ")")
+	case EntityCustomEmoji:
+		f.marker("](" + customEmojiPrefix + escapeMarkdown(e.customEmojiID(), markdownURLChars) + ")")
+	case EntityBlockquote:
+	}
+}
+
+func (f *markdownFormatter) text(s string, code bool) {
+	if s == "" {
+		return
+	}
+	chars := markdownSpecialChars
+	if code {
+		chars = markdownCodeChars
+	}
+	f.b.WriteString(escapeMarkdown(s, chars))
+	f.underscore = false
+}
+
+func (f *markdownFormatter) String() string {
+	return f.b.String()
+}
+
+func escapeMarkdown(s, chars string) string {
+	var b strings.Builder
+	for _, r := range s {
+		// '\r' is ignored by Telegram unless escaped
+		if r == '\r' || strings.ContainsRune(chars, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+type markdownParser struct {
+	src      []rune
+	i        int
+	text     textBuilder
+	open     []*Entity
+	links    []*Entity
+	quote    *Entity
+	entities Entities
+}
+
+// ParseMarkdownV2 parses Telegram MarkdownV2 style markup into plain text and entities.
+func ParseMarkdownV2(s string) (string, Entities, error) {
+	p := &markdownParser{src: []rune(s)}
+	if err := p.parse(); err != nil {
+		return "", nil, err
+	}
+	return p.text.String(), p.entities.Normalize(), nil
+}
+
+func (p *markdownParser) errorf(format string, args ...any) error {
+	return errors.Wrap(ErrInvalidMarkdown, fmt.Sprintf("at %d: ", p.i)+fmt.Sprintf(format, args...))
+}
+
+func (p *markdownParser) peek(offset int) rune {
+	if p.i+offset < len(p.src) {
+		return p.src[p.i+offset]
+	}
+	return 0
+}
+
+func (p *markdownParser) parse() error {
+	lineStart := true
+	for p.i < len(p.src) {
+		if lineStart {
+			lineStart = false
+			p.quoteLine()
+			continue
+		}
+
+		r := p.src[p.i]
+		switch {
+		case r == '\\':
+			if p.i+1 >= len(p.src) {
+				return p.errorf("unterminated escape")
+			}
+			p.text.writeRune(p.src[p.i+1])
+			lineStart = p.src[p.i+1] == '\n'
+			p.i += 2
+		case r == '\r':
+			p.i++
+		case r == '\n':
+			p.text.writeRune(r)
+			lineStart = true
+			p.i++
+		case r == '*':
+			p.toggle(EntityBold, 1)
+		case r == '~':
+			p.toggle(EntityStrikethrough, 1)
+		case r == '_' && p.peek(1) == '_':
+			p.toggle(EntityUnderline, 2) //nolint:gomnd // marker length
+		case r == '_':
+			p.toggle(EntityItalic, 1)
+		case r == '|' && p.peek(1) == '|':
+			p.toggle(EntitySpoiler, 2) //nolint:gomnd // marker length
+		case r == '`':
+			if err := p.code(); err != nil {
+				return err
+			}
+			lineStart = p.text.last == '\n'
+		case r == '[':
+			p.openLink(EntityTextLink, 1)
+		case r == '!' && p.peek(1) == '[':
+			p.openLink(EntityCustomEmoji, 2) //nolint:gomnd // marker length
+		case r == ']':
+			if err := p.closeLink(); err != nil {
+				return err
+			}
+		case strings.ContainsRune(markdownSpecialChars, r):
+			return p.errorf("character %q must be escaped", r)
+		default:
+			p.text.writeRune(r)
+			p.i++
+		}
+	}
+
+	if p.quote != nil {
+		end := p.text.pos
+		if lineStart {
+			// text ends with a line break which doesn't belong to the blockquote
+			end--
+		}
+		p.closeQuote(end)
+	}
+	if len(p.open) > 0 || len(p.links) > 0 {
+		return p.errorf("unclosed entity")
+	}
+	return nil
+}
+
+// quoteLine opens or continues a blockquote on a line starting with '>' and closes it otherwise.
+func (p *markdownParser) quoteLine() {
+	if p.src[p.i] == '>' {
+		p.i++
+		if p.quote == nil {
+			p.quote = &Entity{Type: EntityBlockquote, Offset: p.text.pos}
+		}
+		return
+	}
+	if p.quote != nil {
+		// the line break before the current line doesn't belong to the blockquote
+		p.closeQuote(p.text.pos - 1)
+	}
+}
+
+func (p *markdownParser) closeQuote(end int) {
+	p.quote.Length = end - p.quote.Offset
+	p.entities = append(p.entities, *p.quote)
+	p.quote = nil
+}
+
+func (p *markdownParser) toggle(t EntityType, size int) {
+	p.i += size
+	for i := len(p.open) - 1; i >= 0; i-- {
+		if p.open[i].Type == t {
+			p.open[i].Length = p.text.pos - p.open[i].Offset
+			p.entities = append(p.entities, *p.open[i])
+			p.open = append(p.open[:i], p.open[i+1:]...)
+			return
+		}
+	}
+	p.open = append(p.open, &Entity{Type: t, Offset: p.text.pos})
+}
+
+func (p *markdownParser) openLink(t EntityType, size int) {
+	p.i += size
+	p.links = append(p.links, &Entity{Type: t, Offset: p.text.pos})
+}
+
+func (p *markdownParser) closeLink() error {
+	if len(p.links) == 0 {
+		return p.errorf("character ']' must be escaped")
+	}
+	if p.peek(1) != '(' {
+		return p.errorf("expected '(' after ']'")
+	}
+	p.i += 2
+
+	url, err := p.readUntil(')')
+	if err != nil {
+		return err
+	}
+
+	link := p.links[len(p.links)-1]
+	p.links = p.links[:len(p.links)-1]
+
+	var entity Entity
+	if link.Type == EntityCustomEmoji {
+		if !strings.HasPrefix(url, customEmojiPrefix) {
+			return p.errorf("invalid custom emoji url %q", url)
+		}
+		entity = customEmojiEntity(strings.TrimPrefix(url, customEmojiPrefix))
+	} else {
+		entity = linkEntity(url)
+	}
+	entity.Offset = link.Offset
+	entity.Length = p.text.pos - link.Offset
+	p.entities = append(p.entities, entity)
+	return nil
+}
+
+// readUntil reads escaped content until the unescaped terminator and skips the terminator.
+func (p *markdownParser) readUntil(terminator rune) (string, error) {
+	var b strings.Builder
+	for ; p.i < len(p.src); p.i++ {
+		r := p.src[p.i]
+		switch r {
+		case '\\':
+			if p.i+1 >= len(p.src) {
+				return "", p.errorf("unterminated escape")
+			}
+			p.i++
+			b.WriteRune(p.src[p.i])
+		case terminator:
+			p.i++
+			return b.String(), nil
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return "", p.errorf("expected %q", terminator)
+}
+
+func (p *markdownParser) code() error {
+	if p.peek(1) != '`' || p.peek(2) != '`' { //nolint:gocritic // it's not a string comparison
+		p.i++
+		offset := p.text.pos
+		content, err := p.readUntil('`')
+		if err != nil {
+			return err
+		}
+		p.text.writeString(content)
+		p.entities = append(p.entities, Entity{Type: EntityCode, Offset: offset, Length: p.text.pos - offset})
+		return nil
+	}
+
+	p.i += 3
+	// the first line of a code block is its language
+	var language string
+	for j := p.i; j < len(p.src) && p.src[j] != '`' && p.src[j] != '\\'; j++ {
+		if p.src[j] == '\n' {
+			language = string(p.src[p.i:j])
+			p.i = j + 1
+			break
+		}
+	}
+
+	offset := p.text.pos
+	content, err := p.readUntil('`')
+	if err != nil {
+		return err
+	}
+	if p.peek(0) != '`' || p.peek(1) != '`' {
+		return p.errorf("expected closing ```")
+	}
+	p.i += 2
+
+	p.text.writeString(content)
+	p.entities = append(p.entities, Entity{
+		Type:     EntityCodeBlock,
+		Offset:   offset,
+		Length:   p.text.pos - offset,
+		Language: language,
+	})
+	return nil
+}
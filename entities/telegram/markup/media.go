@@ -1,5 +1,6 @@
 package markup
 
+// PhotoOrVideo is media which can be grouped into an album: Photo, Video, Animation or Document.
 type PhotoOrVideo interface {
 	_photoOrVideo()
 }
@@ -66,6 +67,8 @@ type Document struct {
 	DisableTypeDetection bool   `json:"disable_content_type_detection,omitempty"`
 }
 
+func (d Document) _photoOrVideo() {}
+
 type Audio struct {
 	*File
 
@@ -92,6 +95,8 @@ type Animation struct {
 	FileName  string `json:"file_name,omitempty"`
 }
 
+func (a Animation) _photoOrVideo() {}
+
 type Voice struct {
 	*File
 	Duration int `json:"duration"`
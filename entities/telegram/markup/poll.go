@@ -1,5 +1,10 @@
 package markup
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type PollType string
 
 const (
@@ -9,8 +14,86 @@ const (
 )
 
 type Poll struct {
-	ID          string   `json:"id"`
-	Type        PollType `json:"type"`
-	Question    string   `json:"question"`
-	PollOptions []string `json:"options"`
+	ID          string       `json:"id"`
+	Type        PollType     `json:"type"`
+	Question    string       `json:"question"`
+	PollOptions []PollOption `json:"options"`
+
+	TotalVoterCount       int  `json:"total_voter_count,omitempty"`
+	IsClosed              bool `json:"is_closed,omitempty"`
+	IsAnonymous           bool `json:"is_anonymous"`
+	AllowsMultipleAnswers bool `json:"allows_multiple_answers,omitempty"`
+
+	// CorrectOptionID (Optional). Zero-based index of the correct option, required for PollQuiz.
+	CorrectOptionID *int `json:"correct_option_id,omitempty"`
+
+	// Explanation (Optional). Shown when a user chooses an incorrect answer in PollQuiz.
+	Explanation         string   `json:"explanation,omitempty"`
+	ExplanationEntities Entities `json:"explanation_entities,omitempty"`
+
+	// OpenPeriod (Optional). Amount of time in seconds the poll will be active after creation.
+	// Can't be used together with CloseDate.
+	OpenPeriod int `json:"open_period,omitempty"`
+	// CloseDate (Optional). Unix timestamp when the poll will be automatically closed.
+	CloseDate int64 `json:"close_date,omitempty"`
+}
+
+type PollOption struct {
+	Text       string `json:"text"`
+	VoterCount int    `json:"voter_count"`
+}
+
+// NewPollOptions creates options without votes.
+func NewPollOptions(texts ...string) []PollOption {
+	options := make([]PollOption, 0, len(texts))
+	for _, text := range texts {
+		options = append(options, PollOption{Text: text})
+	}
+	return options
+}
+
+// UnmarshalJSON also accepts a plain string, the format options were stored in before.
+func (o *PollOption) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*o = PollOption{Text: text}
+		return nil
+	}
+
+	type option PollOption
+	return json.Unmarshal(data, (*option)(o))
+}
+
+// UnmarshalJSON defaults IsAnonymous to true, as Telegram does, for polls stored without the field.
+func (p *Poll) UnmarshalJSON(data []byte) error {
+	type poll Poll
+	if err := json.Unmarshal(data, (*poll)(p)); err != nil {
+		return err
+	}
+
+	var fields struct {
+		IsAnonymous *bool `json:"is_anonymous"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if fields.IsAnonymous == nil {
+		p.IsAnonymous = true
+	}
+	return nil
+}
+
+func (p *Poll) IsQuiz() bool {
+	return p.Type == PollQuiz
+}
+
+func (p *Poll) CloseTime() time.Time {
+	if p.CloseDate == 0 {
+		return time.Time{}
+	}
+	return time.Unix(p.CloseDate, 0)
+}
+
+func (p *Poll) SetCloseTime(t time.Time) {
+	p.CloseDate = t.Unix()
 }
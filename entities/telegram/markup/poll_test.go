@@ -0,0 +1,151 @@
+package markup
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPollOption_UnmarshalJSON(t *testing.T) {
+	var poll Poll
+	require.NoError(t, json.Unmarshal([]byte(`{"type":"regular","question":"q","options":["a","b"]}`), &poll))
+	assert.Equal(t, NewPollOptions("a", "b"), poll.PollOptions)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"options":[{"text":"a","voter_count":3}]}`), &poll))
+	assert.Equal(t, []PollOption{{Text: "a", VoterCount: 3}}, poll.PollOptions)
+}
+
+func TestPoll_UnmarshalJSON(t *testing.T) {
+	testCases := []struct {
+		name      string
+		data      string
+		anonymous bool
+	}{
+		{name: "legacy poll without the field", data: `{"type":"regular","question":"q"}`, anonymous: true},
+		{name: "anonymous", data: `{"is_anonymous":true}`, anonymous: true},
+		{name: "public", data: `{"is_anonymous":false}`, anonymous: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var poll Poll
+			require.NoError(t, json.Unmarshal([]byte(tc.data), &poll))
+			assert.Equal(t, tc.anonymous, poll.IsAnonymous)
+		})
+	}
+
+	// the field is kept when the poll is marshalled back
+	data, err := json.Marshal(Poll{IsAnonymous: false})
+	require.NoError(t, err)
+	var poll Poll
+	require.NoError(t, json.Unmarshal(data, &poll))
+	assert.False(t, poll.IsAnonymous)
+}
+
+func TestPoll_Validate(t *testing.T) {
+	zero, outOfRange := 0, 2
+
+	testCases := []struct {
+		name         string
+		poll         Poll
+		expectedErr  error
+		expectedPath string
+	}{
+		{
+			name: "valid regular poll",
+			poll: Poll{
+				Type: PollRegular, Question: "q", PollOptions: NewPollOptions("a", "b"),
+				IsAnonymous: true, AllowsMultipleAnswers: true, OpenPeriod: 60,
+			},
+		},
+		{
+			name: "valid quiz",
+			poll: Poll{
+				Type: PollQuiz, Question: "q", PollOptions: NewPollOptions("a", "b"), CorrectOptionID: &zero,
+				Explanation:         "because",
+				ExplanationEntities: Entities{{Type: EntityBold, Offset: 0, Length: 7}},
+			},
+		},
+		{
+			name:         "quiz without correct option",
+			poll:         Poll{Type: PollQuiz, Question: "q", PollOptions: NewPollOptions("a", "b")},
+			expectedErr:  ErrQuizWithoutAnswer,
+			expectedPath: "poll.correct_option_id",
+		},
+		{
+			name: "correct option out of range",
+			poll: Poll{
+				Type: PollQuiz, Question: "q", PollOptions: NewPollOptions("a", "b"), CorrectOptionID: &outOfRange,
+			},
+			expectedErr:  ErrCorrectOptionOutOfRange,
+			expectedPath: "poll.correct_option_id",
+		},
+		{
+			name: "quiz with multiple answers",
+			poll: Poll{
+				Type: PollQuiz, Question: "q", PollOptions: NewPollOptions("a", "b"), CorrectOptionID: &zero,
+				AllowsMultipleAnswers: true,
+			},
+			expectedErr:  ErrQuizMultipleAnswers,
+			expectedPath: "poll.allows_multiple_answers",
+		},
+		{
+			name: "explanation entity out of bounds",
+			poll: Poll{
+				Type: PollQuiz, Question: "q", PollOptions: NewPollOptions("a", "b"), CorrectOptionID: &zero,
+				Explanation:         "why",
+				ExplanationEntities: Entities{{Type: EntityBold, Offset: 0, Length: 4}},
+			},
+			expectedErr:  ErrEntityOutOfBounds,
+			expectedPath: "poll.explanation.entities[0]",
+		},
+		{
+			name: "explanation in regular poll",
+			poll: Poll{
+				Type: PollRegular, Question: "q", PollOptions: NewPollOptions("a", "b"), Explanation: "why",
+			},
+			expectedErr:  ErrQuizOnlyField,
+			expectedPath: "poll.explanation",
+		},
+		{
+			name: "open period with close date",
+			poll: Poll{
+				Type: PollRegular, Question: "q", PollOptions: NewPollOptions("a", "b"), OpenPeriod: 60, CloseDate: 1,
+			},
+			expectedErr:  ErrPollCloseConflict,
+			expectedPath: "poll.close_date",
+		},
+		{
+			name: "open period too long",
+			poll: Poll{
+				Type: PollRegular, Question: "q", PollOptions: NewPollOptions("a", "b"), OpenPeriod: 601,
+			},
+			expectedErr:  ErrPollOpenPeriod,
+			expectedPath: "poll.open_period",
+		},
+		{
+			name:         "empty option",
+			poll:         Poll{Type: PollRegular, Question: "q", PollOptions: NewPollOptions("a", "")},
+			expectedErr:  ErrEmptyValue,
+			expectedPath: "poll.options[1].text",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := &Validator{}
+			tc.poll.Validate(v, "poll")
+			err := v.Err()
+
+			assert.ErrorIs(t, err, tc.expectedErr)
+			if tc.expectedErr == nil {
+				return
+			}
+			var fieldErr *FieldError
+			require.ErrorAs(t, err, &fieldErr)
+			assert.Equal(t, tc.expectedPath, fieldErr.Path)
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package markup
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// formatter writes markup of a concrete syntax (HTML, MarkdownV2) while the text is walked entity by entity.
+type formatter interface {
+	quoteLine()
+	open(e Entity)
+	close(e Entity)
+	text(s string, code bool)
+	String() string
+}
+
+// UTF16Len returns length of the string in UTF-16 code units, the unit of Entity.Offset and Entity.Length.
+func UTF16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeLen(r)
+	}
+	return n
+}
+
+func runeLen(r rune) int {
+	if r >= 0x10000 { //nolint:gomnd // first rune encoded with surrogate pair
+		return 2 //nolint:gomnd // surrogate pair
+	}
+	return 1
+}
+
+func (e Entity) End() int {
+	return e.Offset + e.Length
+}
+
+func (e Entity) customEmojiID() string {
+	if e.CustomEmoji == nil || e.CustomEmoji.File == nil {
+		return ""
+	}
+	return e.CustomEmoji.FileID
+}
+
+// isCode reports whether the entity can't contain other entities.
+func (e Entity) isCode() bool {
+	return e.Type == EntityCode || e.Type == EntityCodeBlock
+}
+
+// isRenderable reports whether the entity has its own markup. Mentions, hashtags, urls
+// and the like are detected by Telegram from the text itself, so they are rendered as plain text.
+func (e Entity) isRenderable() bool {
+	switch e.Type {
+	case EntityBold, EntityItalic, EntityUnderline, EntityStrikethrough, EntitySpoiler,
+		EntityCode, EntityCodeBlock, EntityBlockquote:
+		return true
+	case EntityTextLink, EntityTMention:
+		return e.URL != ""
+	case EntityCustomEmoji:
+		return e.customEmojiID() != ""
+	case EntityMention, EntityHashtag, EntityCashtag, EntityCommand, EntityURL, EntityEmail, EntityPhone:
+		return false
+	default:
+		return false
+	}
+}
+
+// rank orders entities starting at the same offset: block entities are opened first.
+func (e Entity) rank() int {
+	switch e.Type { //nolint:exhaustive // the rest are equal
+	case EntityBlockquote:
+		return 0
+	case EntityCodeBlock:
+		return 1
+	default:
+		return 2 //nolint:gomnd // lowest rank
+	}
+}
+
+func (e Entity) sameAttrs(other Entity) bool {
+	return e.Type == other.Type &&
+		e.URL == other.URL &&
+		e.Language == other.Language &&
+		e.customEmojiID() == other.customEmojiID()
+}
+
+func sortEntities(entities Entities) {
+	sort.SliceStable(entities, func(i, j int) bool {
+		a, b := entities[i], entities[j]
+		if a.Offset != b.Offset {
+			return a.Offset < b.Offset
+		}
+		if a.rank() != b.rank() {
+			return a.rank() < b.rank()
+		}
+		if a.Length != b.Length {
+			return a.Length > b.Length
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.URL != b.URL {
+			return a.URL < b.URL
+		}
+		return a.Language < b.Language
+	})
+}
+
+// Normalize drops empty entities, merges overlapping and adjacent entities of the same kind
+// and sorts the result by offset. Custom emojis are only merged when they overlap.
+func (es Entities) Normalize() Entities {
+	entities := make(Entities, 0, len(es))
+	for _, e := range es {
+		if e.Length > 0 {
+			entities = append(entities, e)
+		}
+	}
+
+	sort.SliceStable(entities, func(i, j int) bool {
+		return entities[i].Offset < entities[j].Offset
+	})
+
+	merged := make(Entities, 0, len(entities))
+	for _, e := range entities {
+		found := false
+		for i := range merged {
+			m := &merged[i]
+			if !m.sameAttrs(e) {
+				continue
+			}
+			if e.Offset < m.End() || (e.Offset == m.End() && e.Type != EntityCustomEmoji) {
+				if e.End() > m.End() {
+					m.Length = e.End() - m.Offset
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, e)
+		}
+	}
+
+	sortEntities(merged)
+	return merged
+}
+
+func render(text string, entities Entities, f formatter) string {
+	units := utf16.Encode([]rune(text))
+
+	renderable := make(Entities, 0, len(entities))
+	for _, e := range entities {
+		if e.isRenderable() && e.Offset >= 0 && e.End() <= len(units) {
+			renderable = append(renderable, e)
+		}
+	}
+	renderable = renderable.Normalize()
+
+	boundaries := renderBoundaries(units, renderable)
+
+	var (
+		stack []Entity
+		next  int
+	)
+	for bi, pos := range boundaries {
+		if isLineStart(units, pos) && isQuoted(renderable, pos) {
+			// code can't contain the quote marker, so it's closed before
+			if n := len(stack); n > 0 && stack[n-1].isCode() && stack[n-1].End() == pos {
+				f.close(stack[n-1])
+				stack = stack[:n-1]
+			}
+			f.quoteLine()
+		}
+
+		stack = closeEntities(f, stack, pos)
+
+		for ; next < len(renderable) && renderable[next].Offset == pos; next++ {
+			if inCode(stack) {
+				continue
+			}
+			f.open(renderable[next])
+			stack = append(stack, renderable[next])
+		}
+
+		if bi+1 < len(boundaries) {
+			f.text(string(utf16.Decode(units[pos:boundaries[bi+1]])), inCode(stack))
+		}
+	}
+	return f.String()
+}
+
+// closeEntities closes entities which end at pos. Entities opened above the closed ones are reopened,
+// so overlapping entities are split into properly nested pieces.
+func closeEntities(f formatter, stack []Entity, pos int) []Entity {
+	lowest := -1
+	for i, e := range stack {
+		if e.End() == pos {
+			lowest = i
+			break
+		}
+	}
+	if lowest == -1 {
+		return stack
+	}
+
+	var reopen []Entity
+	for i := len(stack) - 1; i >= lowest; i-- {
+		f.close(stack[i])
+		if stack[i].End() > pos {
+			reopen = append([]Entity{stack[i]}, reopen...)
+		}
+	}
+	stack = stack[:lowest]
+
+	for _, e := range reopen {
+		f.open(e)
+		stack = append(stack, e)
+	}
+	return stack
+}
+
+func renderBoundaries(units []uint16, entities Entities) []int {
+	set := map[int]struct{}{0: {}, len(units): {}}
+	for _, e := range entities {
+		set[e.Offset] = struct{}{}
+		set[e.End()] = struct{}{}
+	}
+	for i, u := range units {
+		if u == '\n' {
+			set[i+1] = struct{}{}
+		}
+	}
+
+	boundaries := make([]int, 0, len(set))
+	for pos := range set {
+		boundaries = append(boundaries, pos)
+	}
+	sort.Ints(boundaries)
+	return boundaries
+}
+
+func isLineStart(units []uint16, pos int) bool {
+	return pos < len(units) && (pos == 0 || units[pos-1] == '\n')
+}
+
+func isQuoted(entities Entities, pos int) bool {
+	for _, e := range entities {
+		if e.Type == EntityBlockquote && e.Offset <= pos && pos < e.End() {
+			return true
+		}
+	}
+	return false
+}
+
+func inCode(stack []Entity) bool {
+	for _, e := range stack {
+		if e.isCode() {
+			return true
+		}
+	}
+	return false
+}
+
+// textBuilder accumulates parsed text and tracks its length in UTF-16 code units.
+type textBuilder struct {
+	strings.Builder
+	pos  int
+	last rune
+}
+
+func (b *textBuilder) writeRune(r rune) {
+	b.WriteRune(r)
+	b.pos += runeLen(r)
+	b.last = r
+}
+
+func (b *textBuilder) writeString(s string) {
+	for _, r := range s {
+		b.writeRune(r)
+	}
+}
+
+const (
+	textMentionPrefix = "tg://user?id="
+	customEmojiPrefix = "tg://emoji?id="
+)
+
+func linkEntity(url string) Entity {
+	if strings.HasPrefix(url, textMentionPrefix) {
+		return Entity{Type: EntityTMention, URL: url}
+	}
+	return Entity{Type: EntityTextLink, URL: url}
+}
+
+func customEmojiEntity(id string) Entity {
+	return Entity{
+		Type:        EntityCustomEmoji,
+		CustomEmoji: &Sticker{File: &File{Type: MediaTypeSticker, FileID: id}},
+	}
+}
@@ -0,0 +1,296 @@
+package markup
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderHTML(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		entities Entities
+		expected string
+	}{
+		{
+			name:     "escaping",
+			text:     `<a href="x">&`,
+			expected: "&lt;a href=&#34;x&#34;&gt;&amp;",
+		},
+		{
+			name: "nested",
+			text: "bold italic",
+			entities: Entities{
+				{Type: EntityBold, Offset: 0, Length: 11},
+				{Type: EntityItalic, Offset: 5, Length: 6},
+			},
+			expected: "<b>bold <i>italic</i></b>",
+		},
+		{
+			name: "overlapping",
+			text: "abcdef",
+			entities: Entities{
+				{Type: EntityBold, Offset: 0, Length: 4},
+				{Type: EntityItalic, Offset: 2, Length: 4},
+			},
+			expected: "<b>ab<i>cd</i></b><i>ef</i>",
+		},
+		{
+			name: "utf-16 offsets",
+			text: "😀 link",
+			entities: Entities{
+				{Type: EntityTextLink, Offset: 3, Length: 4, URL: "https://t.me"},
+			},
+			expected: `😀 <a href="https://t.me">link</a>`,
+		},
+		{
+			name: "code block with language",
+			text: "fmt.Println()",
+			entities: Entities{
+				{Type: EntityCodeBlock, Offset: 0, Length: 13, Language: "go"},
+			},
+			expected: `<pre><code class="language-go">fmt.Println()</code></pre>`,
+		},
+		{
+			name: "auto detected entities are plain text",
+			text: "@channel #tag",
+			entities: Entities{
+				{Type: EntityMention, Offset: 0, Length: 8},
+				{Type: EntityHashtag, Offset: 9, Length: 4},
+			},
+			expected: "@channel #tag",
+		},
+		{
+			name: "custom emoji and text mention",
+			text: "👍 user",
+			entities: Entities{
+				customEmojiAt(0, 2, "5368324170671202286"),
+				{Type: EntityTMention, Offset: 3, Length: 4, URL: "tg://user?id=1"},
+			},
+			expected: `<tg-emoji emoji-id="5368324170671202286">👍</tg-emoji> <a href="tg://user?id=1">user</a>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, RenderHTML(tc.text, tc.entities))
+		})
+	}
+}
+
+func TestRenderMarkdownV2(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		entities Entities
+		expected string
+	}{
+		{
+			name:     "escaping",
+			text:     "1. (a) #b!",
+			expected: `1\. \(a\) \#b\!`,
+		},
+		{
+			name: "italic underline",
+			text: "text",
+			entities: Entities{
+				{Type: EntityItalic, Offset: 0, Length: 4},
+				{Type: EntityUnderline, Offset: 0, Length: 4},
+			},
+			expected: "_\r__text__\r_",
+		},
+		{
+			name: "link with escaped url",
+			text: "link",
+			entities: Entities{
+				{Type: EntityTextLink, Offset: 0, Length: 4, URL: "https://t.me/(x)"},
+			},
+			expected: `[link](https://t.me/(x\))`,
+		},
+		{
+			name: "code block",
+			text: "a`b",
+			entities: Entities{
+				{Type: EntityCodeBlock, Offset: 0, Length: 3, Language: "go"},
+			},
+			expected: "```go\na\\`b```",
+		},
+		{
+			name: "blockquote",
+			text: "quote\nsecond line\nplain",
+			entities: Entities{
+				{Type: EntityBlockquote, Offset: 0, Length: 17},
+				{Type: EntityBold, Offset: 6, Length: 6},
+			},
+			expected: ">quote\n>*second* line\nplain",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, RenderMarkdownV2(tc.text, tc.entities))
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	_, _, err := ParseHTML("<b>unclosed")
+	assert.ErrorIs(t, err, ErrInvalidHTML)
+
+	_, _, err = ParseHTML("<b><i>mismatch</b></i>")
+	assert.ErrorIs(t, err, ErrInvalidHTML)
+
+	_, _, err = ParseHTML("<marquee>unsupported</marquee>")
+	assert.ErrorIs(t, err, ErrInvalidHTML)
+
+	_, _, err = ParseMarkdownV2("*unclosed")
+	assert.ErrorIs(t, err, ErrInvalidMarkdown)
+
+	_, _, err = ParseMarkdownV2("not escaped.")
+	assert.ErrorIs(t, err, ErrInvalidMarkdown)
+}
+
+func TestRoundTrip(t *testing.T) {
+	const iterations = 2000
+
+	rnd := rand.New(rand.NewSource(1)) //nolint:gosec // deterministic test data
+	for i := 0; i < iterations; i++ {
+		text, entities := randomMarkup(rnd)
+		expected := entities.Normalize()
+
+		html := RenderHTML(text, entities)
+		parsedText, parsedEntities, err := ParseHTML(html)
+		require.NoError(t, err, html)
+		require.Equal(t, text, parsedText, html)
+		require.Equal(t, expected, parsedEntities, html)
+
+		markdown := RenderMarkdownV2(text, entities)
+		parsedText, parsedEntities, err = ParseMarkdownV2(markdown)
+		require.NoError(t, err, markdown)
+		require.Equal(t, text, parsedText, markdown)
+		require.Equal(t, expected, parsedEntities, markdown)
+	}
+}
+
+func customEmojiAt(offset, length int, id string) Entity {
+	e := customEmojiEntity(id)
+	e.Offset, e.Length = offset, length
+	return e
+}
+
+//nolint:gochecknoglobals // test data
+var (
+	randomChars = []string{
+		"a", "b", "z", " ", "ж", "😀", "\n", "\r",
+		"_", "*", "[", "]", "(", ")", "~", "`", ">", "#", "+", "-", "=", "|", "{", "}", ".", "!", "\\",
+		"<", "&", `"`, "'",
+	}
+	inlineTypes = []EntityType{
+		EntityBold, EntityItalic, EntityUnderline, EntityStrikethrough, EntitySpoiler, EntityTextLink, EntityTMention,
+	}
+	exclusiveTypes = []EntityType{EntityCode, EntityCodeBlock, EntityCustomEmoji}
+)
+
+// randomMarkup generates text with entities which both HTML and MarkdownV2 can express:
+// code, code blocks and custom emojis don't intersect anything, blockquotes cover whole lines
+// and are separated by unquoted lines, other entities overlap arbitrarily.
+func randomMarkup(rnd *rand.Rand) (string, Entities) {
+	var (
+		text  strings.Builder
+		runes []int // utf-16 offset of every rune and the end of text
+		pos   int
+	)
+	for n := rnd.Intn(40); n > 0; n-- {
+		s := randomChars[rnd.Intn(len(randomChars))]
+		runes = append(runes, pos)
+		text.WriteString(s)
+		pos += UTF16Len(s)
+	}
+	runes = append(runes, pos)
+
+	var (
+		entities Entities
+		taken    = make([]bool, pos)
+	)
+	isFree := func(from, to int) bool {
+		for i := from; i < to; i++ {
+			if taken[i] {
+				return false
+			}
+		}
+		return true
+	}
+	randomSpan := func() (int, int) {
+		from := rnd.Intn(len(runes))
+		to := from + rnd.Intn(len(runes)-from)
+		return runes[from], runes[to]
+	}
+
+	for n := rnd.Intn(3); n > 0; n-- {
+		from, to := randomSpan()
+		if from == to || !isFree(from, to) {
+			continue
+		}
+		e := Entity{Type: exclusiveTypes[rnd.Intn(len(exclusiveTypes))], Offset: from, Length: to - from}
+		switch e.Type { //nolint:exhaustive // only exclusive types
+		case EntityCodeBlock:
+			e.Language = []string{"", "go"}[rnd.Intn(2)]
+		case EntityCustomEmoji:
+			e = customEmojiAt(from, to-from, "5368324170671202286")
+		}
+		for i := from; i < to; i++ {
+			taken[i] = true
+		}
+		entities = append(entities, e)
+	}
+
+	entities = append(entities, randomQuotes(rnd, text.String(), taken)...)
+
+	for n := rnd.Intn(6); n > 0; n-- {
+		from, to := randomSpan()
+		if from == to || !isFree(from, to) {
+			continue
+		}
+		e := Entity{Type: inlineTypes[rnd.Intn(len(inlineTypes))], Offset: from, Length: to - from}
+		switch e.Type { //nolint:exhaustive // only inline types
+		case EntityTextLink:
+			e.URL = []string{"https://t.me/a", `https://t.me/(b)\`}[rnd.Intn(2)]
+		case EntityTMention:
+			e.URL = "tg://user?id=42"
+		}
+		entities = append(entities, e)
+	}
+	return text.String(), entities
+}
+
+func randomQuotes(rnd *rand.Rand, text string, taken []bool) Entities {
+	var (
+		quotes Entities
+		pos    int
+		prev   = -2
+	)
+	for i, line := range strings.Split(text, "\n") {
+		length := UTF16Len(line)
+		free := true
+		for j := pos; j < pos+length; j++ {
+			free = free && !taken[j]
+		}
+		if (prev == i-1 && taken[pos-1]) || (pos+length < len(taken) && taken[pos+length]) {
+			free = false
+		}
+		if length > 0 && free && rnd.Intn(3) == 0 {
+			if prev == i-1 {
+				quotes[len(quotes)-1].Length = pos + length - quotes[len(quotes)-1].Offset
+			} else {
+				quotes = append(quotes, Entity{Type: EntityBlockquote, Offset: pos, Length: length})
+			}
+			prev = i
+		}
+		pos += length + 1
+	}
+	return quotes
+}
@@ -0,0 +1,277 @@
+package markup
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-faster/errors"
+)
+
+// Limits of the Telegram Bot API.
+const (
+	MaxMessageLength = 4096
+	MaxCaptionLength = 1024
+	MaxAlbumSize     = 10
+	MinPollOptions   = 2
+	MaxPollOptions   = 10
+
+	MaxPollQuestionLength    = 300
+	MaxPollOptionLength      = 100
+	MaxPollExplanationLength = 200
+	MaxPollExplanationLines  = 2
+	MinPollOpenPeriod        = 5 * time.Second
+	MaxPollOpenPeriod        = 600 * time.Second
+)
+
+var (
+	ErrInvalidMarkup           = errors.New("invalid markup")
+	ErrTooLong                 = errors.Wrap(ErrInvalidMarkup, "too long")
+	ErrEntityOutOfBounds       = errors.Wrap(ErrInvalidMarkup, "entity out of text bounds")
+	ErrEntityWithoutURL        = errors.Wrap(ErrInvalidMarkup, "entity requires url")
+	ErrUnexpectedLanguage      = errors.Wrap(ErrInvalidMarkup, "language is allowed only for pre entity")
+	ErrAlbumSize               = errors.Wrap(ErrInvalidMarkup, "invalid album size")
+	ErrAlbumMixedMedia         = errors.Wrap(ErrInvalidMarkup, "album media can't be mixed")
+	ErrPollOptionsCount        = errors.Wrap(ErrInvalidMarkup, "invalid number of poll options")
+	ErrQuizWithoutAnswer       = errors.Wrap(ErrInvalidMarkup, "quiz requires correct option")
+	ErrEmptyValue              = errors.Wrap(ErrInvalidMarkup, "empty value")
+	ErrCorrectOptionOutOfRange = errors.Wrap(ErrInvalidMarkup, "correct option out of range")
+	ErrQuizOnlyField           = errors.Wrap(ErrInvalidMarkup, "field is allowed only for quiz")
+	ErrQuizMultipleAnswers     = errors.Wrap(ErrInvalidMarkup, "quiz can't allow multiple answers")
+	ErrInvalidPollType         = errors.Wrap(ErrInvalidMarkup, "invalid poll type")
+	ErrPollOpenPeriod          = errors.Wrap(ErrInvalidMarkup, "invalid poll open period")
+	ErrPollCloseConflict       = errors.Wrap(ErrInvalidMarkup, "open period and close date can't be used together")
+)
+
+// FieldError is a validation error of the field at Path, e.g. "entities[1].url".
+type FieldError struct {
+	Path string
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors is a list of field errors. errors.Is and errors.As match any of them.
+type ValidationErrors []*FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// Validator collects field errors of nested structures.
+type Validator struct {
+	errs ValidationErrors
+}
+
+func (v *Validator) Add(path string, err error) {
+	v.errs = append(v.errs, &FieldError{Path: path, Err: err})
+}
+
+func (v *Validator) Addf(path string, err error, format string, args ...any) {
+	v.Add(path, errors.Wrap(err, fmt.Sprintf(format, args...)))
+}
+
+// Err returns collected errors or nil.
+func (v *Validator) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+func joinPath(prefix, field string) string {
+	if prefix == "" {
+		return field
+	}
+	if strings.HasPrefix(field, "[") {
+		return prefix + field
+	}
+	return prefix + "." + field
+}
+
+// ValidateText checks length of the text in UTF-16 code units and its entities.
+func ValidateText(v *Validator, path, text string, entities Entities, maxLength int) {
+	length := UTF16Len(text)
+	if length > maxLength {
+		v.Addf(path, ErrTooLong, "%d of %d", length, maxLength)
+	}
+	entities.validate(v, joinPath(path, "entities"), length)
+}
+
+func (es Entities) validate(v *Validator, path string, textLength int) {
+	for i, e := range es {
+		e.validate(v, joinPath(path, fmt.Sprintf("[%d]", i)), textLength)
+	}
+}
+
+func (e Entity) validate(v *Validator, path string, textLength int) {
+	if e.Offset < 0 || e.Length <= 0 || e.End() > textLength {
+		v.Addf(path, ErrEntityOutOfBounds, "offset %d, length %d, text length %d", e.Offset, e.Length, textLength)
+	}
+	if e.Type == EntityTextLink && e.URL == "" {
+		v.Add(joinPath(path, "url"), ErrEntityWithoutURL)
+	}
+	if e.Type != EntityCodeBlock && e.Language != "" {
+		v.Add(joinPath(path, "language"), ErrUnexpectedLanguage)
+	}
+}
+
+// ValidateCaption checks a media caption. Caption entities aren't stored, so only the length is checked.
+func ValidateCaption(v *Validator, path, caption string) {
+	if length := UTF16Len(caption); length > MaxCaptionLength {
+		v.Addf(path, ErrTooLong, "%d of %d", length, MaxCaptionLength)
+	}
+}
+
+// Validate checks album size, media captions and that media kinds can be grouped:
+// photos and videos can be mixed, documents only with documents and an animation can't be grouped at all.
+func (a Album) Validate(v *Validator, path string) {
+	if len(a) > MaxAlbumSize {
+		v.Addf(path, ErrAlbumSize, "%d of %d", len(a), MaxAlbumSize)
+	}
+
+	var kinds []MediaType
+	for i, media := range a {
+		itemPath := joinPath(path, fmt.Sprintf("[%d]", i))
+		kind, file, err := mediaFile(media)
+		if err != nil {
+			v.Add(itemPath, err)
+			continue
+		}
+		if file == nil || file.Type != kind {
+			v.Add(joinPath(itemPath, "type"), ErrMismatchMediaType)
+		}
+		ValidateCaption(v, joinPath(itemPath, "caption"), mediaCaption(media))
+		kinds = append(kinds, kind)
+	}
+
+	for i, kind := range kinds {
+		if i == 0 {
+			continue
+		}
+		if !canBeGrouped(kinds[0], kind) {
+			v.Addf(joinPath(path, fmt.Sprintf("[%d]", i)), ErrAlbumMixedMedia, "%s with %s", kind, kinds[0])
+		}
+	}
+}
+
+func canBeGrouped(a, b MediaType) bool {
+	visual := func(t MediaType) bool { return t == MediaTypePhoto || t == MediaTypeVideo }
+	switch {
+	case visual(a) && visual(b):
+		return true
+	case a == MediaTypeDocument && b == MediaTypeDocument:
+		return true
+	default:
+		return false
+	}
+}
+
+func mediaCaption(media PhotoOrVideo) string {
+	switch m := media.(type) {
+	case Photo:
+		return m.Caption
+	case *Photo:
+		return m.Caption
+	case Video:
+		return m.Caption
+	case *Video:
+		return m.Caption
+	case Animation:
+		return m.Caption
+	case *Animation:
+		return m.Caption
+	case Document:
+		return m.Caption
+	case *Document:
+		return m.Caption
+	default:
+		return ""
+	}
+}
+
+// Validate checks the poll depending on its type: a quiz requires a correct option,
+// can't allow multiple answers and is the only poll which can have an explanation.
+func (p *Poll) Validate(v *Validator, path string) {
+	if p.Type != PollQuiz && p.Type != PollRegular {
+		v.Addf(joinPath(path, "type"), ErrInvalidPollType, "%q", p.Type)
+	}
+
+	if p.Question == "" {
+		v.Add(joinPath(path, "question"), ErrEmptyValue)
+	}
+	if length := UTF16Len(p.Question); length > MaxPollQuestionLength {
+		v.Addf(joinPath(path, "question"), ErrTooLong, "%d of %d", length, MaxPollQuestionLength)
+	}
+
+	if n := len(p.PollOptions); n < MinPollOptions || n > MaxPollOptions {
+		v.Addf(joinPath(path, "options"), ErrPollOptionsCount, "%d, expected %d-%d", n, MinPollOptions, MaxPollOptions)
+	}
+	for i, option := range p.PollOptions {
+		optionPath := joinPath(path, fmt.Sprintf("options[%d].text", i))
+		if option.Text == "" {
+			v.Add(optionPath, ErrEmptyValue)
+		}
+		if length := UTF16Len(option.Text); length > MaxPollOptionLength {
+			v.Addf(optionPath, ErrTooLong, "%d of %d", length, MaxPollOptionLength)
+		}
+	}
+
+	if p.Type == PollQuiz {
+		p.validateQuiz(v, path)
+	} else {
+		if p.CorrectOptionID != nil {
+			v.Add(joinPath(path, "correct_option_id"), ErrQuizOnlyField)
+		}
+		if p.Explanation != "" || len(p.ExplanationEntities) > 0 {
+			v.Add(joinPath(path, "explanation"), ErrQuizOnlyField)
+		}
+	}
+
+	if p.OpenPeriod != 0 {
+		period := time.Duration(p.OpenPeriod) * time.Second
+		if period < MinPollOpenPeriod || period > MaxPollOpenPeriod {
+			v.Addf(joinPath(path, "open_period"), ErrPollOpenPeriod, "%s, expected %s-%s", period, MinPollOpenPeriod, MaxPollOpenPeriod)
+		}
+		if p.CloseDate != 0 {
+			v.Add(joinPath(path, "close_date"), ErrPollCloseConflict)
+		}
+	}
+}
+
+func (p *Poll) validateQuiz(v *Validator, path string) {
+	switch {
+	case p.CorrectOptionID == nil:
+		v.Add(joinPath(path, "correct_option_id"), ErrQuizWithoutAnswer)
+	case *p.CorrectOptionID < 0 || *p.CorrectOptionID >= len(p.PollOptions):
+		v.Add(joinPath(path, "correct_option_id"), ErrCorrectOptionOutOfRange)
+	}
+
+	if p.AllowsMultipleAnswers {
+		v.Add(joinPath(path, "allows_multiple_answers"), ErrQuizMultipleAnswers)
+	}
+
+	explanationPath := joinPath(path, "explanation")
+	ValidateText(v, explanationPath, p.Explanation, p.ExplanationEntities, MaxPollExplanationLength)
+	if lines := strings.Count(p.Explanation, "\n"); lines > MaxPollExplanationLines {
+		v.Addf(explanationPath, ErrTooLong, "%d line feeds of %d", lines, MaxPollExplanationLines)
+	}
+}
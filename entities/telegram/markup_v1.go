@@ -2,20 +2,23 @@ package telegram
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/Justksenia/common/entities/telegram/markup"
 )
 
 type MarkupV1 struct {
-	Message    string                `json:"message"`
-	Entities   []markup.Entity       `json:"entities,omitempty"`
-	Media      []markup.PhotoOrVideo `json:"media,omitempty"`
-	Audios     []markup.Audio        `json:"audios,omitempty"`
-	Voices     []markup.Voice        `json:"voices,omitempty"`
-	VideoNotes []markup.VideoNote    `json:"video_notes,omitempty"`
-	Stickers   []markup.Sticker      `json:"stickers,omitempty"`
-	Poll       *markup.Poll          `json:"poll,omitempty"`
-	Documents  []markup.Document     `json:"documents,omitempty"`
+	Message    string             `json:"message"`
+	Entities   []markup.Entity    `json:"entities,omitempty"`
+	Media      markup.Album       `json:"media,omitempty"`
+	Audios     []markup.Audio     `json:"audios,omitempty"`
+	Voices     []markup.Voice     `json:"voices,omitempty"`
+	VideoNotes []markup.VideoNote `json:"video_notes,omitempty"`
+	Stickers   []markup.Sticker   `json:"stickers,omitempty"`
+	Poll       *markup.Poll       `json:"poll,omitempty"`
+	Documents  []markup.Document  `json:"documents,omitempty"`
+
+	ReplyMarkup *markup.ReplyMarkup `json:"reply_markup,omitempty"`
 }
 
 func (m *MarkupV1) ToString() (string, error) {
@@ -29,3 +32,79 @@ func (m *MarkupV1) ToString() (string, error) {
 func (m *MarkupV1) FromString(s string) error {
 	return json.Unmarshal([]byte(s), m)
 }
+
+// HTML renders the message with its entities using Telegram HTML parse mode.
+func (m *MarkupV1) HTML() string {
+	return markup.RenderHTML(m.Message, m.Entities)
+}
+
+// MarkdownV2 renders the message with its entities using Telegram MarkdownV2 parse mode.
+func (m *MarkupV1) MarkdownV2() string {
+	return markup.RenderMarkdownV2(m.Message, m.Entities)
+}
+
+// FromHTML sets message and entities parsed from Telegram HTML markup.
+func (m *MarkupV1) FromHTML(s string) error {
+	message, entities, err := markup.ParseHTML(s)
+	if err != nil {
+		return err
+	}
+	m.Message, m.Entities = message, entities
+	return nil
+}
+
+// FromMarkdownV2 sets message and entities parsed from Telegram MarkdownV2 markup.
+func (m *MarkupV1) FromMarkdownV2(s string) error {
+	message, entities, err := markup.ParseMarkdownV2(s)
+	if err != nil {
+		return err
+	}
+	m.Message, m.Entities = message, entities
+	return nil
+}
+
+// Validate checks the markup against limits of the Telegram Bot API.
+// The returned error is markup.ValidationErrors with paths of all invalid fields.
+func (m *MarkupV1) Validate() error {
+	v := &markup.Validator{}
+
+	maxLength := markup.MaxMessageLength
+	if m.hasCaption() {
+		maxLength = markup.MaxCaptionLength
+	}
+	markup.ValidateText(v, "message", m.Message, m.Entities, maxLength)
+
+	m.Media.Validate(v, "media")
+
+	if len(m.Documents) > markup.MaxAlbumSize {
+		v.Addf("documents", markup.ErrAlbumSize, "%d of %d", len(m.Documents), markup.MaxAlbumSize)
+	}
+	for i, d := range m.Documents {
+		markup.ValidateCaption(v, fmt.Sprintf("documents[%d].caption", i), d.Caption)
+	}
+
+	if len(m.Audios) > markup.MaxAlbumSize {
+		v.Addf("audios", markup.ErrAlbumSize, "%d of %d", len(m.Audios), markup.MaxAlbumSize)
+	}
+	for i, a := range m.Audios {
+		markup.ValidateCaption(v, fmt.Sprintf("audios[%d].caption", i), a.Caption)
+	}
+
+	for i, voice := range m.Voices {
+		markup.ValidateCaption(v, fmt.Sprintf("voices[%d].caption", i), voice.Caption)
+	}
+
+	if m.Poll != nil {
+		m.Poll.Validate(v, "poll")
+	}
+
+	if m.ReplyMarkup != nil {
+		m.ReplyMarkup.Validate(v, "reply_markup")
+	}
+	return v.Err()
+}
+
+// hasCaption reports whether the message is sent as a caption of media.
+func (m *MarkupV1) hasCaption() bool {
+	return len(m.Media) > 0 || len(m.Documents) > 0 || len(m.Audios) > 0 || len(m.Voices) > 0
+}
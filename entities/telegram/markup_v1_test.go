@@ -0,0 +1,146 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Justksenia/common/entities/telegram/markup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkupV1_FromString(t *testing.T) {
+	original := &MarkupV1{
+		Message: "album",
+		Media: markup.Album{
+			markup.Photo{File: &markup.File{Type: markup.MediaTypePhoto, FileID: "photo"}, Width: 10, Height: 10},
+			markup.Video{File: &markup.File{Type: markup.MediaTypeVideo, FileID: "video"}, Duration: 5},
+		},
+	}
+
+	s, err := original.ToString()
+	require.NoError(t, err)
+
+	decoded := &MarkupV1{}
+	require.NoError(t, decoded.FromString(s))
+	assert.Equal(t, original, decoded)
+
+	err = decoded.FromString(`{"message":"","media":[{"type":"Voice","file_id":"voice"}]}`)
+	assert.ErrorIs(t, err, markup.ErrUnknownMediaType)
+}
+
+func TestMarkupV1_Validate(t *testing.T) {
+	photo := func() markup.Photo {
+		return markup.Photo{File: &markup.File{Type: markup.MediaTypePhoto, FileID: "photo"}}
+	}
+	correct := 1
+
+	testCases := []struct {
+		name         string
+		markup       MarkupV1
+		expectedErr  error
+		expectedPath string
+	}{
+		{
+			name: "valid markup",
+			markup: MarkupV1{
+				Message:  "😀 bold",
+				Entities: []markup.Entity{{Type: markup.EntityBold, Offset: 3, Length: 4}},
+				Media:    markup.Album{photo(), photo()},
+			},
+		},
+		{
+			name:         "message too long",
+			markup:       MarkupV1{Message: strings.Repeat("a", markup.MaxMessageLength+1)},
+			expectedErr:  markup.ErrTooLong,
+			expectedPath: "message",
+		},
+		{
+			name: "caption too long in utf-16",
+			markup: MarkupV1{
+				Message: strings.Repeat("😀", markup.MaxCaptionLength/2+1),
+				Media:   markup.Album{photo()},
+			},
+			expectedErr:  markup.ErrTooLong,
+			expectedPath: "message",
+		},
+		{
+			name: "entity out of bounds in utf-16",
+			markup: MarkupV1{
+				Message:  "😀",
+				Entities: []markup.Entity{{Type: markup.EntityBold, Offset: 1, Length: 2}},
+			},
+			expectedErr:  markup.ErrEntityOutOfBounds,
+			expectedPath: "message.entities[0]",
+		},
+		{
+			name: "text link without url",
+			markup: MarkupV1{
+				Message:  "link",
+				Entities: []markup.Entity{{Type: markup.EntityTextLink, Offset: 0, Length: 4}},
+			},
+			expectedErr:  markup.ErrEntityWithoutURL,
+			expectedPath: "message.entities[0].url",
+		},
+		{
+			name: "language on code",
+			markup: MarkupV1{
+				Message:  "code",
+				Entities: []markup.Entity{{Type: markup.EntityCode, Offset: 0, Length: 4, Language: "go"}},
+			},
+			expectedErr:  markup.ErrUnexpectedLanguage,
+			expectedPath: "message.entities[0].language",
+		},
+		{
+			name:         "album too big",
+			markup:       MarkupV1{Media: markup.Album{photo(), photo(), photo(), photo(), photo(), photo(), photo(), photo(), photo(), photo(), photo()}},
+			expectedErr:  markup.ErrAlbumSize,
+			expectedPath: "media",
+		},
+		{
+			name: "photo with document",
+			markup: MarkupV1{Media: markup.Album{
+				photo(),
+				markup.Document{File: &markup.File{Type: markup.MediaTypeDocument}},
+			}},
+			expectedErr:  markup.ErrAlbumMixedMedia,
+			expectedPath: "media[1]",
+		},
+		{
+			name: "poll with single option",
+			markup: MarkupV1{Poll: &markup.Poll{
+				Type: markup.PollRegular, Question: "question", PollOptions: markup.NewPollOptions("yes"),
+			}},
+			expectedErr:  markup.ErrPollOptionsCount,
+			expectedPath: "poll.options",
+		},
+		{
+			name: "quiz without correct option",
+			markup: MarkupV1{Poll: &markup.Poll{
+				Type: markup.PollQuiz, Question: "question", PollOptions: markup.NewPollOptions("yes", "no"),
+			}},
+			expectedErr:  markup.ErrQuizWithoutAnswer,
+			expectedPath: "poll.correct_option_id",
+		},
+		{
+			name: "valid quiz",
+			markup: MarkupV1{Poll: &markup.Poll{
+				Type: markup.PollQuiz, Question: "question", PollOptions: markup.NewPollOptions("yes", "no"), CorrectOptionID: &correct,
+			}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.markup.Validate()
+			assert.ErrorIs(t, err, tc.expectedErr)
+			if tc.expectedErr == nil {
+				return
+			}
+
+			var fieldErr *markup.FieldError
+			require.ErrorAs(t, err, &fieldErr)
+			assert.Equal(t, tc.expectedPath, fieldErr.Path)
+		})
+	}
+}
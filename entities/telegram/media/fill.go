@@ -0,0 +1,136 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Justksenia/common/entities/telegram"
+	"github.com/Justksenia/common/entities/telegram/markup"
+	"github.com/Justksenia/common/tracer"
+	"github.com/go-faster/errors"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Fill makes dimensions of the markup consistent with the stored files. Photos and static stickers get
+// their sizes, image documents and static stickers without a thumbnail get a generated one and sizes of
+// existing thumbnails are updated. Files without S3Path are skipped, they should be uploaded first.
+// Video frames can't be decoded in pure Go, so thumbnails of videos are only updated, never generated.
+func (t *Thumbnailer) Fill(ctx context.Context, m *telegram.MarkupV1) error {
+	ctx, span := tracer.StartSpan(ctx, tracer.AutoFillName(), trace.SpanKindInternal)
+	defer span.End()
+
+	for i, media := range m.Media {
+		path := fmt.Sprintf("media[%d]", i)
+		var err error
+		switch item := media.(type) {
+		case markup.Photo:
+			err = t.photo(ctx, path, &item)
+			m.Media[i] = item
+		case *markup.Photo:
+			err = t.photo(ctx, path, item)
+		case markup.Video:
+			err = t.thumbnail(ctx, path, item.Thumbnail)
+		case *markup.Video:
+			err = t.thumbnail(ctx, path, item.Thumbnail)
+		case markup.Animation:
+			err = t.thumbnail(ctx, path, item.Thumbnail)
+		case *markup.Animation:
+			err = t.thumbnail(ctx, path, item.Thumbnail)
+		case markup.Document:
+			err = t.document(ctx, path, &item)
+			m.Media[i] = item
+		case *markup.Document:
+			err = t.document(ctx, path, item)
+		}
+		if err != nil {
+			return span.Error(err)
+		}
+	}
+	for i := range m.Audios {
+		if err := t.thumbnail(ctx, fmt.Sprintf("audios[%d]", i), m.Audios[i].Thumbnail); err != nil {
+			return span.Error(err)
+		}
+	}
+	for i := range m.VideoNotes {
+		if err := t.thumbnail(ctx, fmt.Sprintf("video_notes[%d]", i), m.VideoNotes[i].Thumbnail); err != nil {
+			return span.Error(err)
+		}
+	}
+	for i := range m.Stickers {
+		if err := t.sticker(ctx, fmt.Sprintf("stickers[%d]", i), &m.Stickers[i]); err != nil {
+			return span.Error(err)
+		}
+	}
+	for i := range m.Documents {
+		if err := t.document(ctx, fmt.Sprintf("documents[%d]", i), &m.Documents[i]); err != nil {
+			return span.Error(err)
+		}
+	}
+	return nil
+}
+
+func (t *Thumbnailer) photo(ctx context.Context, path string, p *markup.Photo) error {
+	if !stored(p.File) {
+		return nil
+	}
+	size, err := t.Dimensions(ctx, p.S3Path)
+	if err != nil {
+		return errors.Wrap(err, path)
+	}
+	p.Width, p.Height = size.Width, size.Height
+	return nil
+}
+
+func (t *Thumbnailer) thumbnail(ctx context.Context, path string, thumb *markup.Photo) error {
+	if thumb == nil {
+		return nil
+	}
+	return t.photo(ctx, path+".thumb", thumb)
+}
+
+func (t *Thumbnailer) document(ctx context.Context, path string, d *markup.Document) error {
+	if d.Thumbnail != nil || !stored(d.File) || !strings.HasPrefix(d.MIME, "image/") {
+		return t.thumbnail(ctx, path, d.Thumbnail)
+	}
+
+	thumb, _, err := t.Generate(ctx, d.S3Path)
+	if errors.Is(err, ErrUnsupportedFormat) {
+		// e.g. svg, which can't be rasterized
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, path)
+	}
+	d.Thumbnail = thumb
+	return nil
+}
+
+func (t *Thumbnailer) sticker(ctx context.Context, path string, s *markup.Sticker) error {
+	if s.IsAnimated || s.IsVideo || !stored(s.File) {
+		return t.thumbnail(ctx, path, s.Thumbnail)
+	}
+	if s.Thumbnail != nil {
+		if err := t.thumbnail(ctx, path, s.Thumbnail); err != nil {
+			return err
+		}
+		size, err := t.Dimensions(ctx, s.S3Path)
+		if err != nil {
+			return errors.Wrap(err, path)
+		}
+		s.Width, s.Height = size.Width, size.Height
+		return nil
+	}
+
+	thumb, size, err := t.Generate(ctx, s.S3Path)
+	if err != nil {
+		return errors.Wrap(err, path)
+	}
+	s.Thumbnail = thumb
+	s.Width, s.Height = size.Width, size.Height
+	return nil
+}
+
+func stored(f *markup.File) bool {
+	return f != nil && f.S3Path != ""
+}
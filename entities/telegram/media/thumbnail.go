@@ -0,0 +1,170 @@
+// Package media generates thumbnails and extracts dimensions of media stored in S3,
+// so markups carry consistent Photo thumbnails.
+package media
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"path"
+	"strings"
+
+	// decoders of the supported formats
+	_ "image/gif"
+	_ "image/png"
+
+	"github.com/Justksenia/common/entities/telegram/markup"
+	"github.com/Justksenia/common/s3"
+	"github.com/Justksenia/common/tracer"
+	"github.com/go-faster/errors"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	// ErrUnsupportedFormat is returned for objects which can't be decoded as an image, e.g. videos.
+	ErrUnsupportedFormat = errors.New("unsupported media format")
+	ErrTooLarge          = errors.New("image is too large")
+)
+
+const (
+	defaultMaxSide   = 320
+	defaultQuality   = 85
+	defaultMaxPixels = 50_000_000
+	thumbsDir        = "thumbs"
+	// maxHeaderSize limits how much is read to find out dimensions of an image, e.g. JPEG with large EXIF.
+	maxHeaderSize = 1 << 20
+)
+
+// Options of thumbnails. Thumbnails are always JPEG, as Telegram requires, whatever the format of the source is.
+type Options struct {
+	// MaxSide is the maximal width and height of a thumbnail, 320 by default as Telegram requires.
+	MaxSide int
+	// Quality of JPEG thumbnails from 1 to 100, 85 by default.
+	Quality int
+	// MaxPixels guards against decompression bombs, images with more pixels aren't decoded.
+	MaxPixels int
+}
+
+// Size is dimensions of an image in pixels.
+type Size struct {
+	Width  int
+	Height int
+}
+
+type Thumbnailer struct {
+	storage s3.Storage
+	opts    Options
+}
+
+func NewThumbnailer(storage s3.Storage, opts Options) *Thumbnailer {
+	if opts.MaxSide <= 0 {
+		opts.MaxSide = defaultMaxSide
+	}
+	if opts.Quality <= 0 || opts.Quality > 100 {
+		opts.Quality = defaultQuality
+	}
+	if opts.MaxPixels <= 0 {
+		opts.MaxPixels = defaultMaxPixels
+	}
+	return &Thumbnailer{storage: storage, opts: opts}
+}
+
+// Dimensions returns the size of the image stored under the key. Only the header of the image is decoded.
+func (t *Thumbnailer) Dimensions(ctx context.Context, key string) (Size, error) {
+	ctx, span := tracer.StartSpan(ctx, tracer.AutoFillName(), trace.SpanKindInternal)
+	defer span.End()
+
+	file, err := t.storage.Get(ctx, key)
+	if err != nil {
+		return Size{}, span.Error(errors.Wrap(err, "get object"))
+	}
+	defer file.Close()
+
+	cfg, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return Size{}, span.Error(decodeError(key, err))
+	}
+	return Size{Width: cfg.Width, Height: cfg.Height}, nil
+}
+
+// Generate makes a thumbnail of the image stored under the key and uploads it next to the image
+// into the thumbs directory. Images are only scaled down. It returns the thumbnail and the size of the source image.
+func (t *Thumbnailer) Generate(ctx context.Context, key string) (*markup.Photo, Size, error) {
+	ctx, span := tracer.StartSpan(ctx, tracer.AutoFillName(), trace.SpanKindInternal)
+	defer span.End()
+
+	file, err := t.storage.Get(ctx, key)
+	if err != nil {
+		return nil, Size{}, span.Error(errors.Wrap(err, "get object"))
+	}
+	defer file.Close()
+
+	// the size is checked before the whole image is read and decoded
+	var header bytes.Buffer
+	cfg, _, err := image.DecodeConfig(io.TeeReader(io.LimitReader(file, maxHeaderSize), &header))
+	if err != nil {
+		return nil, Size{}, span.Error(decodeError(key, err))
+	}
+	if cfg.Width*cfg.Height > t.opts.MaxPixels {
+		return nil, Size{}, span.Error(errors.Wrap(ErrTooLarge, fmt.Sprintf("%s: %dx%d", key, cfg.Width, cfg.Height)))
+	}
+	src, _, err := image.Decode(io.MultiReader(&header, file))
+	if err != nil {
+		return nil, Size{}, span.Error(decodeError(key, err))
+	}
+	source := Size{Width: cfg.Width, Height: cfg.Height}
+
+	thumb := resize(src, fit(source, t.opts.MaxSide))
+	var buf bytes.Buffer
+	if err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: t.opts.Quality}); err != nil {
+		return nil, Size{}, span.Error(errors.Wrap(err, "encode thumbnail"))
+	}
+
+	name := strings.TrimSuffix(path.Base(key), path.Ext(key)) + ".jpeg"
+	thumbKey, err := t.storage.Upload(ctx,
+		s3.NewFile(name, path.Join(path.Dir(key), thumbsDir), buf.Bytes()),
+		s3.WithContentType("image/jpeg"),
+	)
+	if err != nil {
+		return nil, Size{}, span.Error(errors.Wrap(err, "upload thumbnail"))
+	}
+
+	return &markup.Photo{
+		File:   &markup.File{Type: markup.MediaTypePhoto, S3Path: thumbKey},
+		Width:  thumb.Bounds().Dx(),
+		Height: thumb.Bounds().Dy(),
+	}, source, nil
+}
+
+func resize(src image.Image, size Size) image.Image {
+	rect := image.Rect(0, 0, size.Width, size.Height)
+	// JPEG has no alpha channel, transparent images are put on white background
+	dst := image.NewRGBA(rect)
+	draw.Draw(dst, rect, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, rect, src, src.Bounds(), draw.Over, nil)
+	return dst
+}
+
+// fit scales the size down to fit into maxSide keeping the aspect ratio.
+func fit(size Size, maxSide int) Size {
+	if size.Width <= maxSide && size.Height <= maxSide {
+		return size
+	}
+	if size.Width >= size.Height {
+		return Size{Width: maxSide, Height: max(1, size.Height*maxSide/size.Width)}
+	}
+	return Size{Width: max(1, size.Width*maxSide/size.Height), Height: maxSide}
+}
+
+func decodeError(key string, err error) error {
+	if errors.Is(err, image.ErrFormat) {
+		return errors.Wrap(ErrUnsupportedFormat, key)
+	}
+	return errors.Wrap(err, fmt.Sprintf("%s: decode image", key))
+}
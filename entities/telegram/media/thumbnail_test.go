@@ -0,0 +1,180 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/Justksenia/common/entities/telegram"
+	"github.com/Justksenia/common/entities/telegram/markup"
+	"github.com/Justksenia/common/s3"
+	"github.com/go-faster/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func uploadImage(t *testing.T, storage s3.Storage, name string, width, height int) string {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 0x80, A: 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+
+	key, err := storage.Upload(context.Background(), s3.NewFile(name, "media", buf.Bytes()))
+	require.NoError(t, err)
+	return key
+}
+
+func TestThumbnailer_Generate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		width    int
+		height   int
+		expected Size
+		key      string
+	}{
+		{name: "landscape", width: 640, height: 400, expected: Size{320, 200}, key: "media/thumbs/image.jpeg"},
+		{name: "portrait", width: 100, height: 800, expected: Size{40, 320}, key: "media/thumbs/image.jpeg"},
+		{name: "small image is not upscaled", width: 50, height: 30, expected: Size{50, 30}, key: "media/thumbs/image.jpeg"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			storage := s3.NewMemoryStorage()
+			key := uploadImage(t, storage, "image.png", tc.width, tc.height)
+
+			thumbnailer := NewThumbnailer(storage, Options{})
+			thumb, source, err := thumbnailer.Generate(ctx, key)
+			require.NoError(t, err)
+			assert.Equal(t, Size{tc.width, tc.height}, source)
+			assert.Equal(t, tc.key, thumb.S3Path)
+			assert.Equal(t, markup.MediaTypePhoto, thumb.Type)
+			assert.Equal(t, tc.expected, Size{thumb.Width, thumb.Height})
+
+			size, err := thumbnailer.Dimensions(ctx, thumb.S3Path)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, size)
+		})
+	}
+}
+
+func TestThumbnailer_Errors(t *testing.T) {
+	ctx := context.Background()
+	storage := s3.NewMemoryStorage()
+	key := uploadImage(t, storage, "image.png", 100, 100)
+	_, err := storage.Upload(ctx, s3.NewFile("video.mp4", "media", []byte("not an image")))
+	require.NoError(t, err)
+
+	_, _, err = NewThumbnailer(storage, Options{}).Generate(ctx, "media/video.mp4")
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	_, _, err = NewThumbnailer(storage, Options{MaxPixels: 100}).Generate(ctx, key)
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
+
+// headerStorage serves only the beginning of objects, reading further fails.
+type headerStorage struct {
+	s3.Storage
+	size int
+}
+
+func (h headerStorage) Get(ctx context.Context, path string, opts ...s3.GetOption) (*s3.File, error) {
+	file, err := h.Storage.Get(ctx, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	data, err := file.Data()
+	if err != nil {
+		return nil, err
+	}
+	body := io.MultiReader(bytes.NewReader(data[:h.size]), iotest.ErrReader(errors.New("read past the header")))
+	return file.WithBody(body, file.Size()), nil
+}
+
+func TestThumbnailer_TooLargeIsNotRead(t *testing.T) {
+	storage := s3.NewMemoryStorage()
+	key := uploadImage(t, storage, "image.png", 2000, 2000)
+
+	_, _, err := NewThumbnailer(headerStorage{Storage: storage, size: 64}, Options{MaxPixels: 1000}).
+		Generate(context.Background(), key)
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
+
+func TestThumbnailer_WebPSource(t *testing.T) {
+	ctx := context.Background()
+	storage := s3.NewMemoryStorage()
+	// 1x1 lossless WebP
+	data := []byte{
+		0x52, 0x49, 0x46, 0x46, 0x1a, 0x00, 0x00, 0x00, 0x57, 0x45, 0x42, 0x50, 0x56, 0x50, 0x38, 0x4c,
+		0x0d, 0x00, 0x00, 0x00, 0x2f, 0x00, 0x00, 0x00, 0x10, 0x07, 0x10, 0x11, 0x11, 0x88, 0x88, 0xfe,
+		0x07, 0x00,
+	}
+	key, err := storage.Upload(ctx, s3.NewFile("image.webp", "media", data))
+	require.NoError(t, err)
+
+	thumb, source, err := NewThumbnailer(storage, Options{}).Generate(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, Size{1, 1}, source)
+	assert.Equal(t, "media/thumbs/image.jpeg", thumb.S3Path)
+}
+
+func TestThumbnailer_Fill(t *testing.T) {
+	ctx := context.Background()
+	storage := s3.NewMemoryStorage()
+	photo := uploadImage(t, storage, "photo.png", 800, 600)
+	thumb := uploadImage(t, storage, "thumb.png", 90, 60)
+	document := uploadImage(t, storage, "document.png", 1000, 500)
+	sticker := uploadImage(t, storage, "sticker.png", 512, 512)
+
+	m := &telegram.MarkupV1{
+		Media: markup.Album{
+			markup.Photo{File: &markup.File{Type: markup.MediaTypePhoto, S3Path: photo}},
+			markup.Video{
+				File:      &markup.File{Type: markup.MediaTypeVideo, S3Path: "media/video.mp4"},
+				Thumbnail: &markup.Photo{File: &markup.File{Type: markup.MediaTypePhoto, S3Path: thumb}, Width: 1, Height: 1},
+			},
+			markup.Photo{File: &markup.File{Type: markup.MediaTypePhoto, FileID: "not uploaded"}},
+		},
+		Stickers: []markup.Sticker{{File: &markup.File{Type: markup.MediaTypeSticker, S3Path: sticker}}},
+		Documents: []markup.Document{
+			{File: &markup.File{Type: markup.MediaTypeDocument, S3Path: document}, MIME: "image/png"},
+			{File: &markup.File{Type: markup.MediaTypeDocument, S3Path: "media/report.pdf"}, MIME: "application/pdf"},
+		},
+	}
+
+	require.NoError(t, NewThumbnailer(storage, Options{}).Fill(ctx, m))
+
+	p, ok := m.Media[0].(markup.Photo)
+	require.True(t, ok)
+	assert.Equal(t, Size{800, 600}, Size{p.Width, p.Height})
+
+	v, ok := m.Media[1].(markup.Video)
+	require.True(t, ok)
+	assert.Equal(t, Size{90, 60}, Size{v.Thumbnail.Width, v.Thumbnail.Height})
+
+	p, ok = m.Media[2].(markup.Photo)
+	require.True(t, ok)
+	assert.Zero(t, p.Width)
+
+	s := m.Stickers[0]
+	assert.Equal(t, Size{512, 512}, Size{s.Width, s.Height})
+	require.NotNil(t, s.Thumbnail)
+	assert.Equal(t, "media/thumbs/sticker.jpeg", s.Thumbnail.S3Path)
+	assert.Equal(t, Size{320, 320}, Size{s.Thumbnail.Width, s.Thumbnail.Height})
+
+	d := m.Documents[0]
+	require.NotNil(t, d.Thumbnail)
+	assert.Equal(t, Size{320, 160}, Size{d.Thumbnail.Width, d.Thumbnail.Height})
+	assert.Nil(t, m.Documents[1].Thumbnail)
+}
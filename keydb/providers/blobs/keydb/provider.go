@@ -0,0 +1,117 @@
+package keydb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Justksenia/common/keydb/redis"
+	"github.com/Justksenia/common/tracer"
+	"github.com/go-faster/errors"
+	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	expirationTime = redis.PersistentTTL
+	instanceName   = "blob_references_storage"
+	keyPrefixRefs  = "blob-refs"
+	keyPrefixLock  = "blob-lock"
+
+	// lockTTL frees the lock of a blob if its holder died before unlocking it.
+	lockTTL           = time.Minute
+	lockRetryInterval = 50 * time.Millisecond
+)
+
+// releaseScript decrements the counter and deletes it when no references are left, so counters don't pile up.
+//
+//nolint:gochecknoglobals // script is loaded once
+var releaseScript = goredis.NewScript(`
+local refs = redis.call('DECR', KEYS[1])
+if refs <= 0 then
+	redis.call('DEL', KEYS[1])
+end
+return refs
+`)
+
+// unlockScript deletes the lock only if it's still held by the caller, it could expire and be taken by someone else.
+//
+//nolint:gochecknoglobals // script is loaded once
+var unlockScript = goredis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// BlobReferencesKeyDBProvider keeps reference counters of deduplicated blobs, it implements dedup.RefCounter.
+type BlobReferencesKeyDBProvider struct {
+	client *redis.Instance
+}
+
+func New(client *redis.KeyDBFactory) *BlobReferencesKeyDBProvider {
+	return &BlobReferencesKeyDBProvider{
+		client: client.NewInstance(instanceName, expirationTime),
+	}
+}
+
+func (p *BlobReferencesKeyDBProvider) Acquire(ctx context.Context, hash string) (int64, error) {
+	ctx, span := tracer.StartSpan(ctx, tracer.AutoFillName(), trace.SpanKindInternal)
+	defer span.End()
+
+	refs, err := p.client.IncrBy(ctx, refsKey(hash), 1)
+	if err != nil {
+		return 0, span.Error(errors.Wrap(err, "increment references"))
+	}
+	return refs, nil
+}
+
+func (p *BlobReferencesKeyDBProvider) Release(ctx context.Context, hash string) (int64, error) {
+	ctx, span := tracer.StartSpan(ctx, tracer.AutoFillName(), trace.SpanKindInternal)
+	defer span.End()
+
+	refs, err := releaseScript.Run(ctx, p.client.Client(), []string{refsKey(hash)}).Int64()
+	if err != nil {
+		return 0, span.Error(errors.Wrap(err, "decrement references"))
+	}
+	return refs, nil
+}
+
+func (p *BlobReferencesKeyDBProvider) Lock(ctx context.Context, hash string) (func(), error) {
+	ctx, span := tracer.StartSpan(ctx, tracer.AutoFillName(), trace.SpanKindInternal)
+	defer span.End()
+
+	key, token := lockKey(hash), uuid.NewString()
+	ticker := time.NewTicker(lockRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		locked, err := p.client.Client().SetNX(ctx, key, token, lockTTL).Result()
+		if err != nil {
+			return nil, span.Error(errors.Wrap(err, "set lock"))
+		}
+		if locked {
+			break
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, span.Error(ctx.Err())
+		}
+	}
+
+	return func() {
+		// the lock expires anyway if it can't be deleted
+		_ = unlockScript.Run(context.WithoutCancel(ctx), p.client.Client(), []string{key}, token).Err()
+	}, nil
+}
+
+func refsKey(hash string) string {
+	return fmt.Sprintf("%s-%s", keyPrefixRefs, hash)
+}
+
+func lockKey(hash string) string {
+	return fmt.Sprintf("%s-%s", keyPrefixLock, hash)
+}
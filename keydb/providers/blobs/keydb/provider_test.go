@@ -0,0 +1,94 @@
+package keydb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Justksenia/common/containers"
+	"github.com/Justksenia/common/keydb/redis"
+	"github.com/Justksenia/common/s3/dedup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+var _ dedup.RefCounter = (*BlobReferencesKeyDBProvider)(nil)
+
+type BlobReferencesProviderTestSuite struct {
+	suite.Suite
+	adapter *BlobReferencesKeyDBProvider
+}
+
+func (s *BlobReferencesProviderTestSuite) SetupSuite() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	redisContainer, err := containers.NewRedis(ctx, containers.RedisConf{})
+	require.NoError(s.T(), err)
+	s.T().Cleanup(func() { _ = redisContainer.Container.Terminate(context.Background()) })
+
+	client, err := redis.New(redis.Config{Addresses: []string{redisContainer.External}})
+	require.NoError(s.T(), err)
+	s.T().Cleanup(func() { _ = client.Close() })
+
+	s.adapter = New(client)
+}
+
+func TestBlobReferencesTestSuite(t *testing.T) {
+	suite.Run(t, new(BlobReferencesProviderTestSuite))
+}
+
+func (s *BlobReferencesProviderTestSuite) TestReferences() {
+	var (
+		t   = s.T()
+		ctx = context.Background()
+	)
+
+	for expected := int64(1); expected <= 2; expected++ {
+		refs, err := s.adapter.Acquire(ctx, t.Name())
+		require.NoError(t, err)
+		assert.Equal(t, expected, refs)
+	}
+
+	for expected := int64(1); expected >= 0; expected-- {
+		refs, err := s.adapter.Release(ctx, t.Name())
+		require.NoError(t, err)
+		assert.Equal(t, expected, refs)
+	}
+
+	exists, err := s.adapter.client.IsExist(ctx, refsKey(t.Name()))
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func (s *BlobReferencesProviderTestSuite) TestLock() {
+	var (
+		t   = s.T()
+		ctx = context.Background()
+	)
+
+	unlock, err := s.adapter.Lock(ctx, t.Name())
+	require.NoError(t, err)
+
+	waitCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	_, err = s.adapter.Lock(waitCtx, t.Name())
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	locked := make(chan struct{})
+	go func() {
+		defer close(locked)
+		unlock, err := s.adapter.Lock(ctx, t.Name())
+		if assert.NoError(t, err) {
+			unlock()
+		}
+	}()
+
+	unlock()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("lock wasn't taken after unlock")
+	}
+}
@@ -0,0 +1,61 @@
+package keydb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Justksenia/common/keydb/redis"
+	"github.com/Justksenia/common/tracer"
+	"github.com/go-faster/errors"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instanceName    = "leases_storage"
+	keyPrefixLeases = "lease"
+)
+
+// LeasesKeyDBProvider grants expiring leases, it implements cron.Locker.
+type LeasesKeyDBProvider struct {
+	client *redis.Instance
+	holder string
+}
+
+// New creates provider which marks leases with the holder, e.g. the pod name, to see who took them.
+func New(client *redis.KeyDBFactory, holder string) *LeasesKeyDBProvider {
+	return &LeasesKeyDBProvider{
+		client: client.NewInstance(instanceName, redis.PersistentTTL),
+		holder: holder,
+	}
+}
+
+func (p *LeasesKeyDBProvider) Acquire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	ctx, span := tracer.StartSpan(ctx, tracer.AutoFillName(), trace.SpanKindInternal)
+	defer span.End()
+
+	acquired, err := p.client.SetNX(ctx, leaseKey(key), p.holder, ttl)
+	if err != nil {
+		return false, span.Error(errors.Wrap(err, "set lease"))
+	}
+	return acquired, nil
+}
+
+// Holder returns the holder of the lease, redis.ErrNoData is returned if the lease isn't held.
+func (p *LeasesKeyDBProvider) Holder(ctx context.Context, key string) (string, error) {
+	ctx, span := tracer.StartSpan(ctx, tracer.AutoFillName(), trace.SpanKindInternal)
+	defer span.End()
+
+	var holder string
+	if err := p.client.Get(ctx, leaseKey(key), &holder); err != nil {
+		if errors.Is(err, redis.ErrNoData) {
+			return "", err
+		}
+		return "", span.Error(errors.Wrap(err, "get lease"))
+	}
+	return holder, nil
+}
+
+func leaseKey(key string) string {
+	return fmt.Sprintf("%s-%s", keyPrefixLeases, key)
+}
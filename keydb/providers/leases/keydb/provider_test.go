@@ -0,0 +1,95 @@
+package keydb
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Justksenia/common/containers"
+	"github.com/Justksenia/common/cron"
+	"github.com/Justksenia/common/keydb/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+var _ cron.Locker = (*LeasesKeyDBProvider)(nil)
+
+type LeasesProviderTestSuite struct {
+	suite.Suite
+	client *redis.KeyDBFactory
+}
+
+func (s *LeasesProviderTestSuite) SetupSuite() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	redisContainer, err := containers.NewRedis(ctx, containers.RedisConf{})
+	require.NoError(s.T(), err)
+	s.T().Cleanup(func() { _ = redisContainer.Container.Terminate(context.Background()) })
+
+	s.client, err = redis.New(redis.Config{Addresses: []string{redisContainer.External}})
+	require.NoError(s.T(), err)
+	s.T().Cleanup(func() { _ = s.client.Close() })
+}
+
+func TestLeasesTestSuite(t *testing.T) {
+	suite.Run(t, new(LeasesProviderTestSuite))
+}
+
+func (s *LeasesProviderTestSuite) TestAcquire() {
+	var (
+		t      = s.T()
+		ctx    = context.Background()
+		first  = New(s.client, "first")
+		second = New(s.client, "second")
+	)
+
+	acquired, err := first.Acquire(ctx, t.Name(), time.Second)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	acquired, err = second.Acquire(ctx, t.Name(), time.Second)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	holder, err := second.Holder(ctx, t.Name())
+	require.NoError(t, err)
+	assert.Equal(t, "first", holder)
+
+	// the lease expires
+	time.Sleep(1100 * time.Millisecond)
+	acquired, err = second.Acquire(ctx, t.Name(), time.Second)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
+
+func (s *LeasesProviderTestSuite) TestSingletonJob() {
+	var (
+		t    = s.T()
+		runs atomic.Int32
+	)
+
+	job := cron.JobFunc(func(context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+
+	schedulers := make([]cron.Scheduler, 3)
+	for i := range schedulers {
+		if i > 0 {
+			time.Sleep(700 * time.Millisecond)
+		}
+		schedulers[i] = cron.NewCronScheduler()
+		require.NoError(t, schedulers[i].AddJob(job, "@every 1s", cron.WithName(t.Name()), cron.WithSingleton(New(s.client, t.Name()))))
+		schedulers[i].Start(context.Background())
+	}
+
+	// slots are aligned to the interval, so replicas started at different times share them
+	time.Sleep(2100 * time.Millisecond)
+	for _, scheduler := range schedulers {
+		require.NoError(t, scheduler.Stop(context.Background()))
+	}
+	assert.InDelta(t, 3, runs.Load(), 1)
+}
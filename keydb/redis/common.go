@@ -2,6 +2,7 @@ package redis
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-faster/errors"
 	"github.com/redis/go-redis/v9"
@@ -75,3 +76,34 @@ func (i *Instance) Delete(ctx context.Context, keys ...string) error {
 	}
 	return nil
 }
+
+// IncrBy increments integer value of the key by n and returns the new value. Missing key is treated as 0.
+func (i *Instance) IncrBy(ctx context.Context, key string, n int64) (int64, error) {
+	_, span := tracer.StartSpan(ctx, tracer.AutoFillName(), trace.SpanKindClient)
+	span.AddAttribute("instance name", i.name)
+	defer span.End()
+
+	value, err := i.client.IncrBy(ctx, key, n).Result()
+	if err != nil {
+		return 0, span.Error(errors.Wrap(err, "redis.IncrBy"))
+	}
+	return value, nil
+}
+
+// SetNX sets the value with ttl only if the key doesn't exist. It returns false if the key exists.
+func (i *Instance) SetNX(ctx context.Context, key string, value any, ttl time.Duration) (bool, error) {
+	_, span := tracer.StartSpan(ctx, tracer.AutoFillName(), trace.SpanKindClient)
+	span.AddAttribute("instance name", i.name)
+	defer span.End()
+
+	b, err := i.serializer.Marshal(value)
+	if err != nil {
+		return false, span.Error(errors.Wrap(err, "marshal"))
+	}
+
+	ok, err := i.client.SetNX(ctx, key, b, ttl).Result()
+	if err != nil {
+		return false, span.Error(errors.Wrap(err, "redis.SetNX"))
+	}
+	return ok, nil
+}
@@ -3,6 +3,7 @@ package redis
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -53,6 +54,30 @@ func (s *RedisTestSuite) TestInstance_Common() {
 		assert.NoError(t, s.instance.Delete(ctx, t.Name()))
 	})
 
+	t.Run("set_nx", func(t *testing.T) {
+		ok, err := s.instance.SetNX(ctx, t.Name(), "first", time.Minute)
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		ok, err = s.instance.SetNX(ctx, t.Name(), "second", time.Minute)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		var val string
+		require.NoError(t, s.instance.Get(ctx, t.Name(), &val))
+		assert.Equal(t, "first", val)
+	})
+
+	t.Run("incr_by", func(t *testing.T) {
+		value, err := s.instance.IncrBy(ctx, t.Name(), 2)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), value)
+
+		value, err = s.instance.IncrBy(ctx, t.Name(), -3)
+		require.NoError(t, err)
+		assert.Equal(t, int64(-1), value)
+	})
+
 	t.Run("del not existed key", func(t *testing.T) {
 		assert.NoError(t, s.instance.Delete(ctx, t.Name()))
 	})
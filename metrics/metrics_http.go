@@ -22,6 +22,7 @@ type HTTPServerConfig struct {
 
 type HTTPServer struct {
 	server *http.Server
+	route  *mux.Router
 }
 
 func NewDefaultHTTPServerConfig() *HTTPServerConfig {
@@ -48,12 +49,18 @@ func NewDefaultHTTPServer(config *HTTPServerConfig) (*HTTPServer, error) {
 			Handler:           http.Handler(route),
 			ReadHeaderTimeout: 0,
 		},
+		route: route,
 	}
 
 	route.Handle(config.MetricsPath, config.Handler)
 	return &srv, nil
 }
 
+// Handle mounts an additional handler next to the metrics, e.g. cron.Handler. It must be called before Start.
+func (s *HTTPServer) Handle(path string, handler http.Handler) {
+	s.route.Handle(path, handler)
+}
+
 func (s *HTTPServer) Start() error {
 	return s.server.ListenAndServe()
 }
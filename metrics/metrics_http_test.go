@@ -3,6 +3,7 @@ package metrics
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -21,3 +22,18 @@ func TestNewHTTPMetricsServer(t *testing.T) {
 	r.NoError(err)
 	r.Equal(http.StatusOK, resp.StatusCode)
 }
+
+func TestHTTPServer_Handle(t *testing.T) {
+	server, err := NewDefaultHTTPServer(NewDefaultHTTPServerConfig())
+	require.NoError(t, err)
+
+	server.Handle("/jobs", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	for path, status := range map[string]int{"/jobs": http.StatusTeapot, DefaultPath: http.StatusOK} {
+		rec := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, status, rec.Code, path)
+	}
+}
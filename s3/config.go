@@ -7,4 +7,8 @@ type Config struct {
 	SecretKey   string
 	Url         string
 	PartitionID string
+	// UsePathStyle addresses objects as url/bucket/key, which S3 compatible storages like MinIO need.
+	UsePathStyle bool
+	// Encryption is applied to every uploaded object unless it's overridden with WithServerSideEncryption.
+	Encryption ServerSideEncryption
 }
@@ -0,0 +1,232 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/go-faster/errors"
+	"go.uber.org/multierr"
+)
+
+// ErrInvalidDestination is returned when the destination is the source itself or overlaps with it,
+// moving would delete the only copy then.
+var ErrInvalidDestination = errors.New("destination overlaps with source")
+
+const (
+	// maxCopyObjectSize is the biggest object CopyObject can copy, bigger ones are copied in parts.
+	maxCopyObjectSize   int64 = 5 << 30
+	defaultCopyPartSize int64 = 512 << 20
+	maxParts            int64 = 10000
+)
+
+// Copy copies the object inside the bucket without transferring its content through the client.
+// Content type, cache control and user metadata are preserved, the copy is encrypted as configured.
+func (f *FileStorage) Copy(ctx context.Context, src, dst string) error {
+	if src == dst {
+		return errors.Wrap(ErrInvalidDestination, dst)
+	}
+	meta, err := f.Head(ctx, src)
+	if err != nil {
+		return err
+	}
+
+	if meta.Size > f.multipartCopyThreshold {
+		return f.multipartCopy(ctx, meta, dst)
+	}
+
+	source, target := f.sse.readHeaders(), f.sse.headers()
+	_, err = f.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:                         aws.String(f.bucket),
+		Key:                            aws.String(dst),
+		CopySource:                     aws.String(f.copySource(src)),
+		CopySourceSSECustomerAlgorithm: source.algorithm,
+		CopySourceSSECustomerKey:       source.customerKey,
+		CopySourceSSECustomerKeyMD5:    source.keyMD5,
+		ServerSideEncryption:           target.encryption,
+		SSEKMSKeyId:                    target.kmsKeyID,
+		SSECustomerAlgorithm:           target.algorithm,
+		SSECustomerKey:                 target.customerKey,
+		SSECustomerKeyMD5:              target.keyMD5,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to copy object")
+	}
+	return nil
+}
+
+// Move copies the object and deletes the source.
+func (f *FileStorage) Move(ctx context.Context, src, dst string) error {
+	if err := f.Copy(ctx, src, dst); err != nil {
+		return err
+	}
+	return f.Delete(ctx, src)
+}
+
+// CopyAll copies all objects under srcPrefix to dstPrefix keeping the rest of their keys.
+// Failed objects don't abort the batch, they are reported as *ObjectError combined with multierr.
+// Prefixes nested into each other are rejected, copies would overwrite objects of the source.
+func (f *FileStorage) CopyAll(ctx context.Context, srcPrefix, dstPrefix string, opts ...BatchOption) error {
+	if err := checkPrefixes(srcPrefix, dstPrefix); err != nil {
+		return err
+	}
+	return f.batch(ctx, srcPrefix, newBatchOptions(opts), func(key string) error {
+		return f.Copy(ctx, key, dstPrefix+strings.TrimPrefix(key, srcPrefix))
+	})
+}
+
+// MoveAll moves all objects under srcPrefix to dstPrefix, reporting failures as CopyAll does.
+func (f *FileStorage) MoveAll(ctx context.Context, srcPrefix, dstPrefix string, opts ...BatchOption) error {
+	if err := checkPrefixes(srcPrefix, dstPrefix); err != nil {
+		return err
+	}
+	return f.batch(ctx, srcPrefix, newBatchOptions(opts), func(key string) error {
+		return f.Move(ctx, key, dstPrefix+strings.TrimPrefix(key, srcPrefix))
+	})
+}
+
+func checkPrefixes(src, dst string) error {
+	if strings.HasPrefix(dst, src) || strings.HasPrefix(src, dst) {
+		return errors.Wrap(ErrInvalidDestination, fmt.Sprintf("%q -> %q", src, dst))
+	}
+	return nil
+}
+
+func (f *FileStorage) batch(ctx context.Context, prefix string, options batchOptions, fn func(key string) error) error {
+	keys, err := f.GetAllPaths(ctx, prefix)
+	if err != nil {
+		return err
+	}
+
+	var (
+		mu     sync.Mutex
+		done   int64
+		result error
+	)
+	parallel(len(keys), options.workers, func(i int) {
+		err := fn(keys[i])
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			result = multierr.Append(result, &ObjectError{Key: keys[i], Err: err})
+		}
+		done++
+		if options.progress != nil {
+			options.progress(done, int64(len(keys)))
+		}
+	})
+	return result
+}
+
+func (f *FileStorage) multipartCopy(ctx context.Context, src *ObjectMeta, dst string) error {
+	target := f.sse.headers()
+	input := &s3.CreateMultipartUploadInput{
+		Bucket:               aws.String(f.bucket),
+		Key:                  aws.String(dst),
+		Metadata:             src.Metadata,
+		ServerSideEncryption: target.encryption,
+		SSEKMSKeyId:          target.kmsKeyID,
+		SSECustomerAlgorithm: target.algorithm,
+		SSECustomerKey:       target.customerKey,
+		SSECustomerKeyMD5:    target.keyMD5,
+	}
+	if src.ContentType != "" {
+		input.ContentType = aws.String(src.ContentType)
+	}
+	if src.CacheControl != "" {
+		input.CacheControl = aws.String(src.CacheControl)
+	}
+
+	upload, err := f.client.CreateMultipartUpload(ctx, input)
+	if err != nil {
+		return errors.Wrap(err, "failed to create multipart upload")
+	}
+
+	parts, err := f.copyParts(ctx, src, dst, upload.UploadId)
+	if err == nil {
+		_, err = f.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+			Bucket:          aws.String(f.bucket),
+			Key:             aws.String(dst),
+			UploadId:        upload.UploadId,
+			MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+		})
+		err = errors.Wrap(err, "failed to complete multipart upload")
+	}
+	if err != nil {
+		// uploaded parts are billed until the upload is aborted
+		_, abortErr := f.client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(f.bucket),
+			Key:      aws.String(dst),
+			UploadId: upload.UploadId,
+		})
+		return multierr.Append(err, errors.Wrap(abortErr, "failed to abort multipart upload"))
+	}
+	return nil
+}
+
+func (f *FileStorage) copyParts(
+	ctx context.Context,
+	src *ObjectMeta,
+	dst string,
+	uploadID *string,
+) ([]types.CompletedPart, error) {
+	// source and target are encrypted with the same customer key, if any
+	headers := f.sse.readHeaders()
+	ranges := partRanges(src.Size, copyPartSize(src.Size, f.minCopyPartSize))
+	parts := make([]types.CompletedPart, len(ranges))
+	errs := make([]error, len(ranges))
+
+	parallel(len(ranges), defaultWorkers, func(i int) {
+		number := aws.Int32(int32(i + 1))
+		resp, err := f.client.UploadPartCopy(ctx, &s3.UploadPartCopyInput{
+			Bucket:          aws.String(f.bucket),
+			Key:             aws.String(dst),
+			UploadId:        uploadID,
+			PartNumber:      number,
+			CopySource:      aws.String(f.copySource(src.Key)),
+			CopySourceRange: aws.String(ranges[i]),
+
+			CopySourceSSECustomerAlgorithm: headers.algorithm,
+			CopySourceSSECustomerKey:       headers.customerKey,
+			CopySourceSSECustomerKeyMD5:    headers.keyMD5,
+			SSECustomerAlgorithm:           headers.algorithm,
+			SSECustomerKey:                 headers.customerKey,
+			SSECustomerKeyMD5:              headers.keyMD5,
+		})
+		if err != nil {
+			errs[i] = errors.Wrap(err, fmt.Sprintf("failed to copy part %d", i+1))
+			return
+		}
+		parts[i] = types.CompletedPart{ETag: resp.CopyPartResult.ETag, PartNumber: number}
+	})
+
+	if err := multierr.Combine(errs...); err != nil {
+		return nil, err
+	}
+	return parts, nil
+}
+
+func (f *FileStorage) copySource(key string) string {
+	return (&url.URL{Path: f.bucket + "/" + key}).EscapedPath()
+}
+
+// copyPartSize returns size of parts the object is copied in, so that there are no more than 10000 of them.
+func copyPartSize(size, minPartSize int64) int64 {
+	return max(minPartSize, (size+maxParts-1)/maxParts)
+}
+
+// partRanges splits object of the given size into byte ranges of at most partSize bytes.
+func partRanges(size, partSize int64) []string {
+	var ranges []string
+	for start := int64(0); start < size; start += partSize {
+		end := min(start+partSize, size) - 1
+		ranges = append(ranges, fmt.Sprintf("bytes=%d-%d", start, end))
+	}
+	return ranges
+}
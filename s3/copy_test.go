@@ -0,0 +1,178 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/multierr"
+)
+
+func TestPartRanges(t *testing.T) {
+	assert.Empty(t, partRanges(0, 10))
+	assert.Equal(t, []string{"bytes=0-9"}, partRanges(10, 10))
+	assert.Equal(t, []string{"bytes=0-9", "bytes=10-19", "bytes=20-24"}, partRanges(25, 10))
+}
+
+func TestCopyPartSize(t *testing.T) {
+	assert.Equal(t, defaultCopyPartSize, copyPartSize(6<<30, defaultCopyPartSize))
+
+	// 5TiB doesn't fit into 10000 parts of 512MiB
+	size := int64(5 << 40)
+	partSize := copyPartSize(size, defaultCopyPartSize)
+	assert.Greater(t, partSize, defaultCopyPartSize)
+	assert.Len(t, partRanges(size, partSize), int(maxParts))
+}
+
+func TestFileStorage_CopySource(t *testing.T) {
+	storage := newTestStorage(t)
+	assert.Equal(t, "bucket/creatives/a%20b/%D1%84.png", storage.copySource("creatives/a b/ф.png"))
+}
+
+func TestParallel(t *testing.T) {
+	var (
+		sum     atomic.Int64
+		running atomic.Int32
+		maximum atomic.Int32
+	)
+	parallel(100, 3, func(i int) {
+		n := running.Add(1)
+		for {
+			m := maximum.Load()
+			if n <= m || maximum.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		sum.Add(int64(i))
+		running.Add(-1)
+	})
+	assert.Equal(t, int64(4950), sum.Load())
+	assert.LessOrEqual(t, maximum.Load(), int32(3))
+}
+
+func TestFileStorage_InvalidDestination(t *testing.T) {
+	ctx := context.Background()
+	fake, storage := newFakeS3(t, 1000)
+	fake.put("a/1.txt", []byte("data"))
+
+	assert.ErrorIs(t, storage.Copy(ctx, "a/1.txt", "a/1.txt"), ErrInvalidDestination)
+	assert.ErrorIs(t, storage.Move(ctx, "a/1.txt", "a/1.txt"), ErrInvalidDestination)
+	for _, prefixes := range [][2]string{{"a/", "a/"}, {"a/", "a/b/"}, {"a/b/", "a/"}} {
+		assert.ErrorIs(t, storage.CopyAll(ctx, prefixes[0], prefixes[1]), ErrInvalidDestination, prefixes)
+		assert.ErrorIs(t, storage.MoveAll(ctx, prefixes[0], prefixes[1]), ErrInvalidDestination, prefixes)
+	}
+	assert.Equal(t, []string{"a/1.txt"}, fake.keys())
+}
+
+func readObject(t *testing.T, storage Storage, key string) []byte {
+	t.Helper()
+	file, err := storage.Get(context.Background(), key)
+	require.NoError(t, err)
+	data, err := file.Data()
+	require.NoError(t, err)
+	return data
+}
+
+func TestFileStorage_CopyMinio(t *testing.T) {
+	ctx := context.Background()
+	storage := newMinioStorage(t)
+
+	upload := func(t *testing.T, name, path string, data []byte) string {
+		t.Helper()
+		key, err := storage.Upload(ctx, NewFile(name, path, data),
+			WithContentType("text/plain"), WithMetadata(map[string]string{"owner": "42"}))
+		require.NoError(t, err)
+		return key
+	}
+
+	t.Run("copy", func(t *testing.T) {
+		src := upload(t, "1.txt", "copy", []byte("hello"))
+		require.NoError(t, storage.Copy(ctx, src, "copy/2.txt"))
+
+		assert.Equal(t, []byte("hello"), readObject(t, storage, "copy/2.txt"))
+		meta, err := storage.Head(ctx, "copy/2.txt")
+		require.NoError(t, err)
+		assert.Equal(t, "text/plain", meta.ContentType)
+		assert.Equal(t, map[string]string{"owner": "42"}, meta.Metadata)
+	})
+
+	t.Run("move", func(t *testing.T) {
+		src := upload(t, "1.txt", "move", []byte("hello"))
+		require.NoError(t, storage.Move(ctx, src, "move/2.txt"))
+
+		assert.Equal(t, []byte("hello"), readObject(t, storage, "move/2.txt"))
+		_, err := storage.Head(ctx, src)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("copy missing", func(t *testing.T) {
+		assert.ErrorIs(t, storage.Copy(ctx, "missing/1.txt", "missing/2.txt"), ErrNotFound)
+	})
+
+	t.Run("multipart copy", func(t *testing.T) {
+		multipart := *storage
+		multipart.multipartCopyThreshold = 0
+		// the minimal part size of S3
+		multipart.minCopyPartSize = 5 << 20
+
+		data := make([]byte, 11<<20)
+		_, err := rand.Read(data)
+		require.NoError(t, err)
+		src := upload(t, "big.bin", "multipart", data)
+
+		require.NoError(t, multipart.Copy(ctx, src, "multipart/copy.bin"))
+		assert.True(t, bytes.Equal(data, readObject(t, storage, "multipart/copy.bin")))
+		meta, err := storage.Head(ctx, "multipart/copy.bin")
+		require.NoError(t, err)
+		assert.Equal(t, "text/plain", meta.ContentType)
+		assert.Equal(t, map[string]string{"owner": "42"}, meta.Metadata)
+		// ETag of multipart objects ends with the number of parts
+		assert.True(t, strings.HasSuffix(meta.ETag, `-3"`), meta.ETag)
+	})
+
+	t.Run("copy all with failures", func(t *testing.T) {
+		for _, name := range []string{"1.txt", "2.txt", "3.txt"} {
+			upload(t, name, "batch", []byte(name))
+		}
+		// the copy of this object exceeds the key length limit of 1024 bytes
+		long := upload(t, strings.Repeat("x", 1000), "batch", []byte("long"))
+
+		var progress []int64
+		err := storage.CopyAll(ctx, "batch/", "batch-copy/"+strings.Repeat("y", 100)+"/",
+			WithWorkers(1),
+			WithBatchProgress(func(done, total int64) {
+				assert.Equal(t, int64(4), total)
+				progress = append(progress, done)
+			}),
+		)
+		require.Error(t, err)
+		assert.Equal(t, []int64{1, 2, 3, 4}, progress)
+
+		errs := multierr.Errors(err)
+		require.Len(t, errs, 1)
+		var objErr *ObjectError
+		require.ErrorAs(t, errs[0], &objErr)
+		assert.Equal(t, long, objErr.Key)
+
+		paths, err := storage.GetAllPaths(ctx, "batch-copy/")
+		require.NoError(t, err)
+		assert.Len(t, paths, 3)
+	})
+
+	t.Run("move all", func(t *testing.T) {
+		for _, name := range []string{"1.txt", "2.txt"} {
+			upload(t, name, "move-all", []byte(name))
+		}
+		require.NoError(t, storage.MoveAll(ctx, "move-all/", "moved/"))
+
+		paths, err := storage.GetAllPaths(ctx, "move-all/")
+		require.NoError(t, err)
+		assert.Empty(t, paths)
+		assert.Equal(t, []byte("2.txt"), readObject(t, storage, "moved/2.txt"))
+	})
+}
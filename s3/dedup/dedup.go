@@ -0,0 +1,179 @@
+// Package dedup stores blobs in s3.Storage by their content, so identical content is stored once.
+package dedup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/Justksenia/common/s3"
+	"github.com/Justksenia/common/tracer"
+	"github.com/go-faster/errors"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var ErrInvalidKey = errors.New("key of deduplicated blob expected")
+
+// RefCounter keeps number of references to every blob.
+type RefCounter interface {
+	// Acquire adds a reference to the blob and returns the new number of references.
+	Acquire(ctx context.Context, hash string) (int64, error)
+	// Release removes a reference from the blob and returns the number of left references.
+	Release(ctx context.Context, hash string) (int64, error)
+	// Lock waits until no one else holds the lock of the blob and takes it.
+	Lock(ctx context.Context, hash string) (unlock func(), err error)
+}
+
+// Store is a content-addressed layer over s3.Storage. A blob is stored under SHA-256 of its content
+// and deleted when the last reference to it is released.
+//
+// Release holds the lock of the blob while deleting it, so a concurrent Put adds its reference
+// either before the last one is released or after the blob is deleted and uploads it again.
+type Store struct {
+	storage s3.Storage
+	refs    RefCounter
+	prefix  string
+}
+
+func New(storage s3.Storage, refs RefCounter, prefix string) *Store {
+	return &Store{
+		storage: storage,
+		refs:    refs,
+		prefix:  strings.TrimSuffix(prefix, "/"),
+	}
+}
+
+// Put stores the content unless a blob with the same content exists and adds a reference to it.
+// The returned key depends on the content only, so it's stable across uploads.
+// The content is spooled to a temporary file while hashing, so it isn't held in memory.
+func (s *Store) Put(ctx context.Context, r io.Reader, opts ...s3.UploadOption) (string, error) {
+	ctx, span := tracer.StartSpan(ctx, tracer.AutoFillName(), trace.SpanKindInternal)
+	defer span.End()
+
+	spool, err := os.CreateTemp("", "dedup-*")
+	if err != nil {
+		return "", span.Error(errors.Wrap(err, "create temporary file"))
+	}
+	defer func() {
+		_ = spool.Close()
+		_ = os.Remove(spool.Name())
+	}()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(spool, hash), r)
+	if err != nil {
+		return "", span.Error(errors.Wrap(err, "read content"))
+	}
+	sum := hex.EncodeToString(hash.Sum(nil))
+	dir, name := s.location(sum)
+	key := dir + "/" + name
+	span.AddAttribute("key", key)
+
+	refs, err := s.acquire(ctx, sum)
+	if err != nil {
+		return "", span.Error(err)
+	}
+
+	// the first reference uploads the blob, the rest only check that it wasn't lost
+	upload := refs == 1
+	if !upload {
+		_, err = s.storage.Head(ctx, key)
+		switch {
+		case errors.Is(err, s3.ErrNotFound):
+			upload = true
+		case err != nil:
+			return "", span.Error(s.rollback(ctx, sum, errors.Wrap(err, "check blob")))
+		}
+	}
+
+	if upload {
+		if _, err = spool.Seek(0, io.SeekStart); err != nil {
+			return "", span.Error(s.rollback(ctx, sum, errors.Wrap(err, "rewind temporary file")))
+		}
+		if _, err = s.storage.Upload(ctx, s3.NewStreamFile(name, dir, spool, size), opts...); err != nil {
+			return "", span.Error(s.rollback(ctx, sum, errors.Wrap(err, "upload blob")))
+		}
+	}
+	return key, nil
+}
+
+// Release removes a reference to the blob and deletes the blob if it was the last one.
+func (s *Store) Release(ctx context.Context, key string) (deleted bool, err error) {
+	ctx, span := tracer.StartSpan(ctx, tracer.AutoFillName(), trace.SpanKindInternal)
+	defer span.End()
+
+	sum, err := s.hash(key)
+	if err != nil {
+		return false, span.Error(err)
+	}
+
+	unlock, err := s.refs.Lock(ctx, sum)
+	if err != nil {
+		return false, span.Error(errors.Wrap(err, "lock blob"))
+	}
+	defer unlock()
+
+	refs, err := s.refs.Release(ctx, sum)
+	if err != nil {
+		return false, span.Error(errors.Wrap(err, "release reference"))
+	}
+	if refs > 0 {
+		return false, nil
+	}
+
+	if err = s.storage.Delete(ctx, key); err != nil {
+		return false, span.Error(errors.Wrap(err, "delete blob"))
+	}
+	return true, nil
+}
+
+// Get opens the blob for reading.
+func (s *Store) Get(ctx context.Context, key string, opts ...s3.GetOption) (*s3.File, error) {
+	if _, err := s.hash(key); err != nil {
+		return nil, err
+	}
+	return s.storage.Get(ctx, key, opts...)
+}
+
+// acquire adds a reference under the lock of the blob, so it doesn't happen while the blob is being deleted.
+func (s *Store) acquire(ctx context.Context, sum string) (int64, error) {
+	unlock, err := s.refs.Lock(ctx, sum)
+	if err != nil {
+		return 0, errors.Wrap(err, "lock blob")
+	}
+	defer unlock()
+
+	refs, err := s.refs.Acquire(ctx, sum)
+	if err != nil {
+		return 0, errors.Wrap(err, "acquire reference")
+	}
+	return refs, nil
+}
+
+func (s *Store) rollback(ctx context.Context, sum string, err error) error {
+	if _, releaseErr := s.refs.Release(context.WithoutCancel(ctx), sum); releaseErr != nil {
+		return errors.Wrap(err, fmt.Sprintf("release reference: %v", releaseErr))
+	}
+	return err
+}
+
+// location spreads blobs over directories by the first byte of the hash.
+func (s *Store) location(sum string) (dir, name string) {
+	return path.Join(s.prefix, sum[:2]), sum
+}
+
+func (s *Store) hash(key string) (string, error) {
+	sum := path.Base(key)
+	if raw, err := hex.DecodeString(sum); err != nil || len(raw) != sha256.Size {
+		return "", errors.Wrap(ErrInvalidKey, key)
+	}
+	if dir, name := s.location(sum); dir+"/"+name != key {
+		return "", errors.Wrap(ErrInvalidKey, key)
+	}
+	return sum, nil
+}
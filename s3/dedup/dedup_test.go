@@ -0,0 +1,138 @@
+package dedup
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Justksenia/common/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	const sum = "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+
+	var (
+		ctx     = context.Background()
+		storage = s3.NewMemoryStorage()
+		store   = New(storage, NewMemoryRefCounter(), "blobs/")
+	)
+
+	first, err := store.Put(ctx, strings.NewReader("hello world"), s3.WithContentType("text/plain"))
+	require.NoError(t, err)
+	assert.Equal(t, "blobs/b9/"+sum, first)
+
+	second, err := store.Put(ctx, strings.NewReader("hello world"))
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	meta, err := storage.Head(ctx, first)
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain", meta.ContentType)
+
+	file, err := store.Get(ctx, first)
+	require.NoError(t, err)
+	data, err := file.Data()
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(data))
+
+	deleted, err := store.Release(ctx, first)
+	require.NoError(t, err)
+	assert.False(t, deleted)
+	_, err = storage.Head(ctx, first)
+	require.NoError(t, err)
+
+	deleted, err = store.Release(ctx, second)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	_, err = storage.Head(ctx, first)
+	assert.ErrorIs(t, err, s3.ErrNotFound)
+}
+
+func TestStore_LostBlobIsUploaded(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		storage = s3.NewMemoryStorage()
+		store   = New(storage, NewMemoryRefCounter(), "blobs")
+	)
+
+	key, err := store.Put(ctx, strings.NewReader("content"))
+	require.NoError(t, err)
+	require.NoError(t, storage.Delete(ctx, key))
+
+	_, err = store.Put(ctx, strings.NewReader("content"))
+	require.NoError(t, err)
+	_, err = storage.Head(ctx, key)
+	assert.NoError(t, err)
+}
+
+func TestStore_InvalidKey(t *testing.T) {
+	store := New(s3.NewMemoryStorage(), NewMemoryRefCounter(), "blobs")
+
+	for _, key := range []string{
+		"blobs/xx/not-a-hash",
+		"other/b9/b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+		"blobs/00/b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+	} {
+		_, err := store.Release(context.Background(), key)
+		assert.ErrorIs(t, err, ErrInvalidKey, key)
+	}
+}
+
+// blockingStorage blocks deletes until they are released.
+type blockingStorage struct {
+	s3.Storage
+	deleting chan struct{}
+	release  chan struct{}
+}
+
+func (b *blockingStorage) Delete(ctx context.Context, path string) error {
+	close(b.deleting)
+	<-b.release
+	return b.Storage.Delete(ctx, path)
+}
+
+func TestStore_PutWhileReleasing(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		storage = &blockingStorage{
+			Storage:  s3.NewMemoryStorage(),
+			deleting: make(chan struct{}),
+			release:  make(chan struct{}),
+		}
+		store = New(storage, NewMemoryRefCounter(), "blobs")
+	)
+
+	key, err := store.Put(ctx, strings.NewReader("content"))
+	require.NoError(t, err)
+
+	released := make(chan bool)
+	go func() {
+		deleted, err := store.Release(ctx, key)
+		assert.NoError(t, err)
+		released <- deleted
+	}()
+	<-storage.deleting
+
+	put := make(chan struct{})
+	go func() {
+		defer close(put)
+		_, err := store.Put(ctx, strings.NewReader("content"))
+		assert.NoError(t, err)
+	}()
+
+	select {
+	case <-put:
+		t.Fatal("put finished while the blob was being deleted")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(storage.release)
+	assert.True(t, <-released)
+	<-put
+
+	_, err = storage.Head(ctx, key)
+	assert.NoError(t, err)
+}
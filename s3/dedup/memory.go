@@ -0,0 +1,65 @@
+package dedup
+
+import (
+	"context"
+	"sync"
+)
+
+var _ RefCounter = (*MemoryRefCounter)(nil)
+
+// MemoryRefCounter keeps references in memory. It's meant for unit tests.
+type MemoryRefCounter struct {
+	mu    sync.Mutex
+	refs  map[string]int64
+	locks map[string]chan struct{}
+}
+
+func NewMemoryRefCounter() *MemoryRefCounter {
+	return &MemoryRefCounter{
+		refs:  make(map[string]int64),
+		locks: make(map[string]chan struct{}),
+	}
+}
+
+func (m *MemoryRefCounter) Acquire(_ context.Context, hash string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.refs[hash]++
+	return m.refs[hash], nil
+}
+
+func (m *MemoryRefCounter) Release(_ context.Context, hash string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.refs[hash]--
+	refs := m.refs[hash]
+	if refs <= 0 {
+		delete(m.refs, hash)
+	}
+	return refs, nil
+}
+
+func (m *MemoryRefCounter) Lock(ctx context.Context, hash string) (func(), error) {
+	for {
+		m.mu.Lock()
+		released, locked := m.locks[hash]
+		if !locked {
+			released = make(chan struct{})
+			m.locks[hash] = released
+			m.mu.Unlock()
+			return func() {
+				m.mu.Lock()
+				defer m.mu.Unlock()
+				delete(m.locks, hash)
+				close(released)
+			}, nil
+		}
+		m.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
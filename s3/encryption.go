@@ -0,0 +1,89 @@
+package s3
+
+import (
+	"crypto/md5" //nolint:gosec // md5 of the key is required by S3 as an integrity check
+	"encoding/base64"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/go-faster/errors"
+)
+
+var ErrInvalidEncryption = errors.New("invalid server-side encryption")
+
+type SSEMode string
+
+const (
+	SSENone SSEMode = ""
+	// SSES3 encrypts objects with keys managed by the storage.
+	SSES3 SSEMode = "SSE-S3"
+	// SSEKMS encrypts objects with a key of the key management service.
+	SSEKMS SSEMode = "SSE-KMS"
+	// SSEC encrypts objects with a key provided with every request, the storage doesn't keep it.
+	SSEC SSEMode = "SSE-C"
+)
+
+// customerKeySize is the size of AES-256 key required by SSE-C.
+const customerKeySize = 32
+
+// ServerSideEncryption describes how the storage encrypts objects at rest.
+type ServerSideEncryption struct {
+	Mode SSEMode
+	// KMSKeyID is used with SSEKMS, the default key of the bucket is used if it's empty.
+	KMSKeyID string
+	// CustomerKey is a 256-bit key used with SSEC. The same key must be provided to read the object.
+	CustomerKey []byte
+}
+
+func (e ServerSideEncryption) Validate() error {
+	switch e.Mode {
+	case SSENone, SSES3, SSEKMS:
+		return nil
+	case SSEC:
+		if len(e.CustomerKey) != customerKeySize {
+			return errors.Wrap(ErrInvalidEncryption, fmt.Sprintf("customer key must be %d bytes", customerKeySize))
+		}
+		return nil
+	default:
+		return errors.Wrap(ErrInvalidEncryption, fmt.Sprintf("unknown mode %q", e.Mode))
+	}
+}
+
+// sseHeaders are values of encryption headers of a request.
+type sseHeaders struct {
+	encryption  types.ServerSideEncryption
+	kmsKeyID    *string
+	algorithm   *string
+	customerKey *string
+	keyMD5      *string
+}
+
+func (e ServerSideEncryption) headers() sseHeaders {
+	switch e.Mode { //nolint:exhaustive // no headers without encryption
+	case SSES3:
+		return sseHeaders{encryption: types.ServerSideEncryptionAes256}
+	case SSEKMS:
+		h := sseHeaders{encryption: types.ServerSideEncryptionAwsKms}
+		if e.KMSKeyID != "" {
+			h.kmsKeyID = aws.String(e.KMSKeyID)
+		}
+		return h
+	case SSEC:
+		sum := md5.Sum(e.CustomerKey) //nolint:gosec // required by S3
+		return sseHeaders{
+			algorithm:   aws.String(string(types.ServerSideEncryptionAes256)),
+			customerKey: aws.String(base64.StdEncoding.EncodeToString(e.CustomerKey)),
+			keyMD5:      aws.String(base64.StdEncoding.EncodeToString(sum[:])),
+		}
+	}
+	return sseHeaders{}
+}
+
+// readHeaders are headers needed to read the object. Only SSE-C requires the key on reads.
+func (e ServerSideEncryption) readHeaders() sseHeaders {
+	if e.Mode != SSEC {
+		return sseHeaders{}
+	}
+	return e.headers()
+}
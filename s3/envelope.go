@@ -0,0 +1,343 @@
+package s3
+
+import (
+	"bufio"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"strconv"
+
+	"github.com/go-faster/errors"
+)
+
+var ErrNotEncrypted = errors.New("object isn't encrypted with envelope encryption")
+
+const (
+	// envelopeChunkSize is the size of plaintext sealed at once, so content is streamed and ranges are readable.
+	envelopeChunkSize = 64 << 10
+	dataKeySize       = 32
+
+	metaKeyID     = "envelope-key-id"
+	metaKey       = "envelope-key"
+	metaNonce     = "envelope-nonce"
+	metaChunkSize = "envelope-chunk-size"
+)
+
+var _ Storage = (*EncryptedStorage)(nil)
+
+// EncryptedStorage encrypts objects on the client before they reach the underlying storage,
+// so the content stays confidential even on an untrusted storage.
+//
+// Every object is encrypted with its own random data key using AES-256-GCM in chunks. The data key
+// is wrapped with a key encryption key of the KeyProvider and stored in the object metadata.
+// Presigned URLs give the encrypted content.
+type EncryptedStorage struct {
+	Storage
+	keys KeyProvider
+}
+
+func NewEncryptedStorage(storage Storage, keys KeyProvider) *EncryptedStorage {
+	return &EncryptedStorage{Storage: storage, keys: keys}
+}
+
+func (e *EncryptedStorage) Upload(ctx context.Context, file *File, opts ...UploadOption) (string, error) {
+	dataKey := make([]byte, dataKeySize)
+	nonce := make([]byte, envelopeNonceSize)
+	for _, b := range [][]byte{dataKey, nonce} {
+		if _, err := rand.Read(b); err != nil {
+			return "", errors.Wrap(err, "generate data key")
+		}
+	}
+
+	keyID, wrapped, err := e.keys.WrapKey(ctx, dataKey)
+	if err != nil {
+		return "", errors.Wrap(err, "wrap data key")
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	options := newUploadOptions(opts)
+	metadata := make(map[string]string, len(options.metadata)+4) //nolint:gomnd // envelope fields
+	for k, v := range options.metadata {
+		metadata[k] = v
+	}
+	metadata[metaKeyID] = keyID
+	metadata[metaKey] = base64.StdEncoding.EncodeToString(wrapped)
+	metadata[metaNonce] = base64.StdEncoding.EncodeToString(nonce)
+	metadata[metaChunkSize] = strconv.Itoa(envelopeChunkSize)
+
+	size := UnknownSize
+	if file.size != UnknownSize {
+		size = encryptedSize(file.size, envelopeChunkSize)
+	}
+	encrypted := file.WithBody(&sealReader{
+		src:       bufio.NewReader(options.body(file)),
+		aead:      aead,
+		nonce:     nonce,
+		chunk:     make([]byte, envelopeChunkSize),
+		chunkSize: envelopeChunkSize,
+	}, size)
+
+	// progress is reported by the plaintext reader above
+	return e.Storage.Upload(ctx, encrypted, append(opts, WithMetadata(metadata), WithUploadProgress(nil))...)
+}
+
+func (e *EncryptedStorage) Get(ctx context.Context, path string, opts ...GetOption) (*File, error) {
+	options := newGetOptions(opts)
+	meta, err := e.Storage.Head(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	env, err := e.open(ctx, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	plainSize := decryptedSize(meta.Size, env.chunkSize)
+	start, end, err := options.bounds(plainSize)
+	if err != nil {
+		return nil, err
+	}
+
+	sealedChunk := int64(env.chunkSize + env.aead.Overhead())
+	first, last := start/int64(env.chunkSize), max(end-1, 0)/int64(env.chunkSize)
+	inner := []GetOption{WithRange(first*sealedChunk, (last-first+1)*sealedChunk)}
+	if options.customerKey != nil {
+		inner = append(inner, WithCustomerKey(options.customerKey))
+	}
+
+	file, err := e.Storage.Get(ctx, path, inner...)
+	if err != nil {
+		return nil, err
+	}
+
+	body := &openReader{
+		src:       file,
+		aead:      env.aead,
+		nonce:     env.nonce,
+		counter:   uint64(first),
+		final:     uint64(chunksCount(meta.Size, env.chunkSize) - 1),
+		chunk:     make([]byte, sealedChunk),
+		chunkSize: env.chunkSize,
+		skip:      int(start - first*int64(env.chunkSize)),
+	}
+	limited := struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(body, end-start), file}
+	return file.WithBody(options.body(limited, end-start), end-start), nil
+}
+
+// Head returns metadata of the object with the size of the decrypted content.
+func (e *EncryptedStorage) Head(ctx context.Context, key string) (*ObjectMeta, error) {
+	meta, err := e.Storage.Head(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	env, err := parseEnvelope(meta.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	meta.Size = decryptedSize(meta.Size, env.chunkSize)
+	return meta, nil
+}
+
+// List returns objects with sizes of the decrypted content, as Head does. The sizes are computed
+// for the chunk size objects are uploaded with, as List doesn't return metadata.
+func (e *EncryptedStorage) List(prefix string) *ObjectIterator {
+	objects := e.Storage.List(prefix)
+	return NewObjectIterator(func(ctx context.Context) ([]ObjectInfo, bool, error) {
+		if !objects.Next(ctx) {
+			return nil, false, objects.Err()
+		}
+		info := objects.Object()
+		info.Size = decryptedSize(info.Size, envelopeChunkSize)
+		return []ObjectInfo{info}, true, nil
+	})
+}
+
+type envelope struct {
+	keyID     string
+	wrapped   []byte
+	nonce     []byte
+	chunkSize int
+	aead      cipher.AEAD
+}
+
+func parseEnvelope(metadata map[string]string) (*envelope, error) {
+	keyID, ok := metadata[metaKeyID]
+	if !ok {
+		return nil, ErrNotEncrypted
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(metadata[metaKey])
+	if err != nil {
+		return nil, errors.Wrap(err, "decode wrapped key")
+	}
+	nonce, err := base64.StdEncoding.DecodeString(metadata[metaNonce])
+	if err != nil || len(nonce) != envelopeNonceSize {
+		return nil, errors.New("invalid envelope nonce")
+	}
+	chunkSize, err := strconv.Atoi(metadata[metaChunkSize])
+	if err != nil || chunkSize <= 0 {
+		return nil, errors.New("invalid envelope chunk size")
+	}
+	return &envelope{keyID: keyID, wrapped: wrapped, nonce: nonce, chunkSize: chunkSize}, nil
+}
+
+func (e *EncryptedStorage) open(ctx context.Context, meta *ObjectMeta) (*envelope, error) {
+	env, err := parseEnvelope(meta.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := e.keys.UnwrapKey(ctx, env.keyID, env.wrapped)
+	if err != nil {
+		return nil, errors.Wrap(err, "unwrap data key")
+	}
+	if env.aead, err = newAEAD(dataKey); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+const envelopeNonceSize = 12
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "create cipher")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "create gcm")
+	}
+	return aead, nil
+}
+
+// chunkNonce derives nonce of the chunk from the object nonce, so every chunk has a unique one.
+func chunkNonce(dst, nonce []byte, counter uint64) []byte {
+	dst = append(dst[:0], nonce...)
+	tail := binary.BigEndian.Uint64(dst[len(dst)-8:])
+	binary.BigEndian.PutUint64(dst[len(dst)-8:], tail^counter)
+	return dst
+}
+
+// chunkAAD marks the last chunk, so truncation of the content is detected.
+func chunkAAD(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+func chunksCount(encrypted int64, chunkSize int) int64 {
+	sealed := int64(chunkSize + gcmTagSize)
+	return max((encrypted+sealed-1)/sealed, 1)
+}
+
+const gcmTagSize = 16
+
+func encryptedSize(plain int64, chunkSize int) int64 {
+	chunks := plain/int64(chunkSize) + 1
+	if plain > 0 && plain%int64(chunkSize) == 0 {
+		chunks--
+	}
+	return plain + chunks*gcmTagSize
+}
+
+func decryptedSize(encrypted int64, chunkSize int) int64 {
+	return max(encrypted-chunksCount(encrypted, chunkSize)*gcmTagSize, 0)
+}
+
+// sealReader encrypts the source chunk by chunk.
+type sealReader struct {
+	src       *bufio.Reader
+	aead      cipher.AEAD
+	nonce     []byte
+	scratch   []byte
+	counter   uint64
+	chunk     []byte
+	chunkSize int
+	out       []byte
+	done      bool
+}
+
+func (r *sealReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(r.src, r.chunk[:r.chunkSize])
+		final := false
+		switch {
+		case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF):
+			final = true
+		case err != nil:
+			return 0, err
+		default:
+			if _, err = r.src.Peek(1); errors.Is(err, io.EOF) {
+				final = true
+			} else if err != nil {
+				return 0, err
+			}
+		}
+
+		r.scratch = chunkNonce(r.scratch, r.nonce, r.counter)
+		r.out = r.aead.Seal(r.out[:0], r.scratch, r.chunk[:n], chunkAAD(final))
+		r.counter++
+		r.done = final
+	}
+
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// openReader decrypts chunks of the source starting at counter, skipping first skip bytes of plaintext.
+type openReader struct {
+	src       io.Reader
+	aead      cipher.AEAD
+	nonce     []byte
+	scratch   []byte
+	counter   uint64
+	final     uint64
+	chunk     []byte
+	chunkSize int
+	skip      int
+	out       []byte
+	done      bool
+}
+
+func (r *openReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(r.src, r.chunk)
+		// the last chunk is shorter, while a missing chunk is an error
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, errors.Wrap(err, "read encrypted chunk")
+		}
+
+		r.scratch = chunkNonce(r.scratch, r.nonce, r.counter)
+		r.out, err = r.aead.Open(r.out[:0], r.scratch, r.chunk[:n], chunkAAD(r.counter == r.final))
+		if err != nil {
+			return 0, errors.Wrap(err, "decrypt chunk")
+		}
+		r.done = r.counter == r.final || n < len(r.chunk)
+		r.counter++
+
+		skip := min(r.skip, len(r.out))
+		r.out, r.skip = r.out[skip:], r.skip-skip
+	}
+
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
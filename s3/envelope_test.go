@@ -0,0 +1,166 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKeys(t *testing.T) *StaticKeyProvider {
+	t.Helper()
+	keys, err := NewStaticKeyProvider("v2", map[string][]byte{
+		"v1": bytes.Repeat([]byte{1}, 32),
+		"v2": bytes.Repeat([]byte{2}, 32),
+	})
+	require.NoError(t, err)
+	return keys
+}
+
+func TestEncryptedStorage(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		memory  = NewMemoryStorage()
+		storage = NewEncryptedStorage(memory, newTestKeys(t))
+		rnd     = rand.New(rand.NewSource(1)) //nolint:gosec // deterministic test data
+	)
+
+	for _, size := range []int{0, 1, envelopeChunkSize - 1, envelopeChunkSize, envelopeChunkSize + 1, 3*envelopeChunkSize + 5} {
+		content := make([]byte, size)
+		rnd.Read(content)
+
+		key, err := storage.Upload(ctx, NewStreamFile("file", "encrypted", bytes.NewReader(content), UnknownSize),
+			WithContentType("application/pdf"), WithMetadata(map[string]string{"owner": "42"}))
+		require.NoError(t, err)
+
+		raw, err := memory.Head(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, encryptedSize(int64(size), envelopeChunkSize), raw.Size)
+		assert.Equal(t, "42", raw.Metadata["owner"])
+
+		stored, err := memory.Get(ctx, key)
+		require.NoError(t, err)
+		ciphertext, err := stored.Data()
+		require.NoError(t, err)
+		if size > 0 {
+			assert.False(t, bytes.Contains(ciphertext, content[:min(size, 16)]))
+		}
+
+		meta, err := storage.Head(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, int64(size), meta.Size)
+
+		file, err := storage.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, "application/pdf", file.ContentType())
+		data, err := file.Data()
+		require.NoError(t, err)
+		assert.Equal(t, content, data, "size %d", size)
+
+		for i := 0; i < 20 && size > 0; i++ {
+			offset := rnd.Int63n(int64(size))
+			length := rnd.Int63n(int64(size)) + 1
+			file, err = storage.Get(ctx, key, WithRange(offset, length))
+			require.NoError(t, err)
+			data, err = file.Data()
+			require.NoError(t, err)
+			assert.Equal(t, content[offset:min(offset+length, int64(size))], data, "size %d, range %d+%d", size, offset, length)
+		}
+	}
+}
+
+func TestEncryptedStorage_List(t *testing.T) {
+	ctx := context.Background()
+	storage := NewEncryptedStorage(NewMemoryStorage(), newTestKeys(t))
+	for i, size := range []int{0, 10, 2*envelopeChunkSize + 1} {
+		_, err := storage.Upload(ctx, NewFile(fmt.Sprintf("%d.bin", i), "encrypted", make([]byte, size)))
+		require.NoError(t, err)
+	}
+
+	var listed int
+	it := storage.List("encrypted/")
+	for it.Next(ctx) {
+		listed++
+		meta, err := storage.Head(ctx, it.Object().Key)
+		require.NoError(t, err)
+		assert.Equal(t, meta.Size, it.Object().Size, it.Object().Key)
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, 3, listed)
+}
+
+func TestEncryptedStorage_Tampering(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		memory  = NewMemoryStorage()
+		storage = NewEncryptedStorage(memory, newTestKeys(t))
+		content = bytes.Repeat([]byte("secret"), envelopeChunkSize)
+	)
+
+	key, err := storage.Upload(ctx, NewFile("file", "encrypted", content))
+	require.NoError(t, err)
+
+	stored, err := memory.Get(ctx, key)
+	require.NoError(t, err)
+	ciphertext, err := stored.Data()
+	require.NoError(t, err)
+	meta, err := memory.Head(ctx, key)
+	require.NoError(t, err)
+
+	reupload := func(data []byte) {
+		_, err = memory.Upload(ctx, NewFile("file", "encrypted", data), WithMetadata(meta.Metadata))
+		require.NoError(t, err)
+	}
+	read := func() error {
+		file, err := storage.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		_, err = io.ReadAll(file)
+		return err
+	}
+
+	flipped := bytes.Clone(ciphertext)
+	flipped[len(flipped)/2] ^= 1
+	reupload(flipped)
+	assert.Error(t, read())
+
+	// dropping the last chunk must be detected as well
+	reupload(ciphertext[:envelopeChunkSize+gcmTagSize])
+	assert.Error(t, read())
+
+	reupload(ciphertext)
+	assert.NoError(t, read())
+
+	other := NewEncryptedStorage(memory, func() KeyProvider {
+		keys, err := NewStaticKeyProvider("v3", map[string][]byte{"v3": bytes.Repeat([]byte{3}, 32)})
+		require.NoError(t, err)
+		return keys
+	}())
+	_, err = other.Get(ctx, key)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	_, err = memory.Upload(ctx, NewFile("plain", "encrypted", content))
+	require.NoError(t, err)
+	_, err = storage.Get(ctx, "encrypted/plain")
+	assert.ErrorIs(t, err, ErrNotEncrypted)
+}
+
+func TestServerSideEncryption(t *testing.T) {
+	assert.NoError(t, ServerSideEncryption{Mode: SSEKMS, KMSKeyID: "key"}.Validate())
+	assert.ErrorIs(t, ServerSideEncryption{Mode: SSEC, CustomerKey: []byte("short")}.Validate(), ErrInvalidEncryption)
+	assert.ErrorIs(t, ServerSideEncryption{Mode: "unknown"}.Validate(), ErrInvalidEncryption)
+
+	headers := ServerSideEncryption{Mode: SSEC, CustomerKey: bytes.Repeat([]byte{0}, 32)}.headers()
+	assert.Equal(t, "AES256", *headers.algorithm)
+	assert.Equal(t, "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=", *headers.customerKey)
+	assert.Equal(t, "cLyPS3KoaSFGi/joRB3OUQ==", *headers.keyMD5)
+
+	assert.Empty(t, ServerSideEncryption{Mode: SSES3}.readHeaders())
+	assert.Equal(t, "AES256", string(ServerSideEncryption{Mode: SSES3}.headers().encryption))
+}
@@ -0,0 +1,194 @@
+package s3
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 serves the part of S3 API used by listing, batch deletes and downloads, so paging and batching
+// of FileStorage are tested without a real storage.
+type fakeS3 struct {
+	pageSize int
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	// failGet and failDelete are keys which requests fail with AccessDenied.
+	failGet      map[string]bool
+	failDelete   map[string]bool
+	listRequests int
+	deleteSizes  []int
+}
+
+func newFakeS3(t *testing.T, pageSize int) (*fakeS3, *FileStorage) {
+	t.Helper()
+
+	fake := &fakeS3{
+		pageSize:   pageSize,
+		objects:    map[string][]byte{},
+		failGet:    map[string]bool{},
+		failDelete: map[string]bool{},
+	}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	storage, err := New(&Config{
+		Bucket:       "bucket",
+		Region:       "us-east-1",
+		AccessKey:    "access",
+		SecretKey:    "secret",
+		Url:          server.URL,
+		UsePathStyle: true,
+	})
+	require.NoError(t, err)
+	return fake, storage
+}
+
+func (f *fakeS3) put(key string, data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[key] = data
+}
+
+func (f *fakeS3) keys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := make([]string, 0, len(f.objects))
+	for key := range f.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/bucket"), "/")
+	switch {
+	case r.Method == http.MethodGet && key == "" && r.URL.Query().Get("list-type") == "2":
+		f.list(w, r)
+	case r.Method == http.MethodPost && key == "" && r.URL.Query().Has("delete"):
+		f.delete(w, r)
+	case r.Method == http.MethodGet:
+		f.get(w, key)
+	default:
+		http.Error(w, "not implemented", http.StatusNotImplemented)
+	}
+}
+
+type fakeObject struct {
+	Key  string `xml:"Key"`
+	Size int    `xml:"Size"`
+	ETag string `xml:"ETag"`
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, r *http.Request) {
+	f.listRequests++
+
+	var (
+		prefix = r.URL.Query().Get("prefix")
+		after  = r.URL.Query().Get("continuation-token")
+		keys   []string
+	)
+	for key := range f.objects {
+		if strings.HasPrefix(key, prefix) && key > after {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	result := struct {
+		XMLName               xml.Name     `xml:"ListBucketResult"`
+		Name                  string       `xml:"Name"`
+		Prefix                string       `xml:"Prefix"`
+		KeyCount              int          `xml:"KeyCount"`
+		IsTruncated           bool         `xml:"IsTruncated"`
+		NextContinuationToken string       `xml:"NextContinuationToken,omitempty"`
+		Contents              []fakeObject `xml:"Contents"`
+	}{Name: "bucket", Prefix: prefix}
+	if len(keys) > f.pageSize {
+		keys = keys[:f.pageSize]
+		result.IsTruncated = true
+		result.NextContinuationToken = keys[len(keys)-1]
+	}
+	for _, key := range keys {
+		result.Contents = append(result.Contents, fakeObject{Key: key, Size: len(f.objects[key]), ETag: `"etag"`})
+	}
+	result.KeyCount = len(keys)
+	writeXML(w, http.StatusOK, result)
+}
+
+func (f *fakeS3) delete(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Objects []struct {
+			Key string `xml:"Key"`
+		} `xml:"Object"`
+	}
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.deleteSizes = append(f.deleteSizes, len(req.Objects))
+
+	type deleteError struct {
+		Key     string `xml:"Key"`
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	result := struct {
+		XMLName xml.Name      `xml:"DeleteResult"`
+		Errors  []deleteError `xml:"Error"`
+	}{}
+	for _, obj := range req.Objects {
+		if f.failDelete[obj.Key] {
+			result.Errors = append(result.Errors, deleteError{Key: obj.Key, Code: "AccessDenied", Message: "Access Denied"})
+			continue
+		}
+		delete(f.objects, obj.Key)
+	}
+	writeXML(w, http.StatusOK, result)
+}
+
+func (f *fakeS3) get(w http.ResponseWriter, key string) {
+	data, ok := f.objects[key]
+	switch {
+	case f.failGet[key]:
+		writeError(w, http.StatusForbidden, "AccessDenied")
+	case !ok:
+		writeError(w, http.StatusNotFound, "NoSuchKey")
+	default:
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("ETag", `"etag"`)
+		_, _ = w.Write(data)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	writeXML(w, status, struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string   `xml:"Code"`
+		Message string   `xml:"Message"`
+	}{Code: code, Message: code})
+}
+
+func writeXML(w http.ResponseWriter, status int, v any) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprint(w, xml.Header+string(data))
+}
@@ -2,13 +2,24 @@ package s3
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"strings"
 )
 
+// UnknownSize is the size of a file streamed from a reader of unknown length.
+const UnknownSize int64 = -1
+
+// File is an object of the storage. Files returned by FileStorage.Get stream the object body,
+// so they must be closed after use.
 type File struct {
-	name string
-	path string
-	data io.Reader
+	name        string
+	path        string
+	data        io.Reader
+	size        int64
+	contentType string
+	etag        string
+	metadata    map[string]string
 }
 
 func (f *File) Name() string {
@@ -19,7 +30,57 @@ func (f *File) Path() string {
 	return f.path
 }
 
+func (f *File) key() string {
+	return fmt.Sprintf("%s/%s", f.path, f.name)
+}
+
+func baseName(key string) string {
+	if i := strings.LastIndexByte(key, '/'); i != -1 {
+		return key[i+1:]
+	}
+	return key
+}
+
+// Size returns length of the content in bytes or UnknownSize.
+func (f *File) Size() int64 {
+	return f.size
+}
+
+func (f *File) ContentType() string {
+	return f.contentType
+}
+
+func (f *File) ETag() string {
+	return f.etag
+}
+
+// Metadata returns user metadata of a downloaded file.
+func (f *File) Metadata() map[string]string {
+	return f.metadata
+}
+
+// WithBody returns copy of the file with other content, e.g. transformed content of the original file.
+func (f *File) WithBody(r io.Reader, size int64) *File {
+	c := *f
+	c.data = r
+	c.size = size
+	return &c
+}
+
+func (f *File) Read(p []byte) (int, error) {
+	return f.data.Read(p)
+}
+
+func (f *File) Close() error {
+	if closer, ok := f.data.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Data reads the whole content and closes the file.
 func (f *File) Data() ([]byte, error) {
+	defer f.Close()
 	return io.ReadAll(f.data)
 }
 
@@ -28,5 +89,52 @@ func NewFile(name, path string, data []byte) *File {
 		name: name,
 		path: path,
 		data: bytes.NewReader(data),
+		size: int64(len(data)),
+	}
+}
+
+// NewStreamFile creates file which content is read from r while uploading.
+// Size may be UnknownSize, then the content is buffered part by part.
+func NewStreamFile(name, path string, r io.Reader, size int64) *File {
+	return &File{
+		name: name,
+		path: path,
+		data: r,
+		size: size,
+	}
+}
+
+// ProgressFunc is called as the content is transferred. Total is UnknownSize when the length isn't known.
+type ProgressFunc func(transferred, total int64)
+
+type progressReader struct {
+	io.Reader
+	closer      io.Closer
+	transferred int64
+	total       int64
+	progress    ProgressFunc
+}
+
+func newProgressReader(r io.Reader, total int64, progress ProgressFunc) *progressReader {
+	p := &progressReader{Reader: r, total: total, progress: progress}
+	if closer, ok := r.(io.Closer); ok {
+		p.closer = closer
+	}
+	return p
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.Reader.Read(b)
+	if n > 0 {
+		p.transferred += int64(n)
+		p.progress(p.transferred, p.total)
+	}
+	return n, err
+}
+
+func (p *progressReader) Close() error {
+	if p.closer != nil {
+		return p.closer.Close()
 	}
+	return nil
 }
@@ -0,0 +1,58 @@
+package s3
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressReader(t *testing.T) {
+	var calls [][2]int64
+	r := newProgressReader(bytes.NewReader(make([]byte, 10)), 10, func(transferred, total int64) {
+		calls = append(calls, [2]int64{transferred, total})
+	})
+
+	buf := make([]byte, 4)
+	for {
+		if _, err := r.Read(buf); err == io.EOF {
+			break
+		}
+	}
+	assert.Equal(t, [][2]int64{{4, 10}, {8, 10}, {10, 10}}, calls)
+	assert.NoError(t, r.Close())
+}
+
+func TestFile_Data(t *testing.T) {
+	file := NewStreamFile("name", "path", io.NopCloser(bytes.NewReader([]byte("data"))), UnknownSize)
+	data, err := file.Data()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), data)
+	assert.Equal(t, UnknownSize, file.Size())
+	assert.Equal(t, int64(3), NewFile("name", "path", []byte("abc")).Size())
+}
+
+func TestGetOptions_RangeHeader(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opts     []GetOption
+		expected *string
+	}{
+		{name: "whole object"},
+		{name: "from offset", opts: []GetOption{WithRange(10, 0)}, expected: aws.String("bytes=10-")},
+		{name: "range", opts: []GetOption{WithRange(10, 5)}, expected: aws.String("bytes=10-14")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var options getOptions
+			for _, opt := range tc.opts {
+				opt(&options)
+			}
+			assert.Equal(t, tc.expected, options.rangeHeader())
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package s3
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/go-faster/errors"
+)
+
+var ErrUnknownKey = errors.New("unknown key encryption key")
+
+// KeyProvider wraps data keys of EncryptedStorage with key encryption keys, e.g. ones kept in a KMS.
+type KeyProvider interface {
+	// WrapKey encrypts the data key with the current key encryption key and returns its id.
+	WrapKey(ctx context.Context, dataKey []byte) (keyID string, wrapped []byte, err error)
+	// UnwrapKey decrypts the data key with the key encryption key of the id.
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+var _ KeyProvider = (*StaticKeyProvider)(nil)
+
+// StaticKeyProvider keeps key encryption keys in memory, e.g. loaded from secrets. Old keys stay
+// to decrypt existing objects, while new objects are encrypted with the current one.
+type StaticKeyProvider struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// NewStaticKeyProvider creates provider from 256-bit keys by their ids.
+func NewStaticKeyProvider(current string, keys map[string][]byte) (*StaticKeyProvider, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, errors.Wrap(ErrUnknownKey, current)
+	}
+
+	p := &StaticKeyProvider{current: current, keys: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if len(key) != dataKeySize {
+			return nil, errors.Errorf("key %q must be %d bytes", id, dataKeySize)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		p.keys[id] = aead
+	}
+	return p, nil
+}
+
+func (p *StaticKeyProvider) WrapKey(_ context.Context, dataKey []byte) (string, []byte, error) {
+	aead := p.keys[p.current]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(dataKey)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, errors.Wrap(err, "generate nonce")
+	}
+	return p.current, aead.Seal(nonce, nonce, dataKey, []byte(p.current)), nil
+}
+
+func (p *StaticKeyProvider) UnwrapKey(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	aead, ok := p.keys[keyID]
+	if !ok {
+		return nil, errors.Wrap(ErrUnknownKey, keyID)
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, errors.New("wrapped key is too short")
+	}
+	key, err := aead.Open(nil, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], []byte(keyID))
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("unwrap with key %q", keyID))
+	}
+	return key, nil
+}
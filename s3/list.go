@@ -0,0 +1,118 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/go-faster/errors"
+)
+
+// ObjectInfo describes an object without its content.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+	ETag         string
+}
+
+// ObjectMeta is ObjectInfo extended with headers and user metadata of the object.
+type ObjectMeta struct {
+	ObjectInfo
+	ContentType  string
+	CacheControl string
+	Metadata     map[string]string
+}
+
+// ObjectIterator walks all objects under a prefix, requesting the next page of keys when the current one is over.
+//
+//	it := storage.List("creatives/")
+//	for it.Next(ctx) {
+//		info := it.Object()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
+type ObjectIterator struct {
+	fetch   PageFunc
+	done    bool
+	page    []ObjectInfo
+	current ObjectInfo
+	err     error
+}
+
+// PageFunc returns the next page of objects and whether there are more pages.
+type PageFunc func(ctx context.Context) (page []ObjectInfo, more bool, err error)
+
+func NewObjectIterator(fetch PageFunc) *ObjectIterator {
+	return &ObjectIterator{fetch: fetch}
+}
+
+// List returns iterator over objects which keys start with prefix.
+func (f *FileStorage) List(prefix string) *ObjectIterator {
+	paginator := s3.NewListObjectsV2Paginator(f.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(f.bucket),
+		Prefix: aws.String(prefix),
+	})
+	return NewObjectIterator(func(ctx context.Context) ([]ObjectInfo, bool, error) {
+		resp, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, false, errors.Wrap(err, "failed to list objects")
+		}
+		page := make([]ObjectInfo, 0, len(resp.Contents))
+		for _, obj := range resp.Contents {
+			page = append(page, ObjectInfo{
+				Key:          aws.ToString(obj.Key),
+				Size:         aws.ToInt64(obj.Size),
+				LastModified: aws.ToTime(obj.LastModified),
+				ETag:         aws.ToString(obj.ETag),
+			})
+		}
+		return page, paginator.HasMorePages(), nil
+	})
+}
+
+func (it *ObjectIterator) Next(ctx context.Context) bool {
+	for len(it.page) == 0 {
+		if it.err != nil || it.done {
+			return false
+		}
+		page, more, err := it.fetch(ctx)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page, it.done = page, !more
+	}
+
+	it.current = it.page[0]
+	it.page = it.page[1:]
+	return true
+}
+
+func (it *ObjectIterator) Object() ObjectInfo {
+	return it.current
+}
+
+func (it *ObjectIterator) Err() error {
+	return it.err
+}
+
+// ObjectError is a failure of an operation on a single object of a batch.
+type ObjectError struct {
+	Key  string
+	Code string
+	Err  error
+}
+
+func (e *ObjectError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("object %q: %v", e.Key, e.Err)
+	}
+	return fmt.Sprintf("object %q: %s: %v", e.Key, e.Code, e.Err)
+}
+
+func (e *ObjectError) Unwrap() error {
+	return e.Err
+}
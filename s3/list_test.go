@@ -0,0 +1,80 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	cmnlogger "github.com/Justksenia/common/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestFileStorage_List(t *testing.T) {
+	fake, storage := newFakeS3(t, 3)
+	for i := 0; i < 8; i++ {
+		fake.put(fmt.Sprintf("creatives/%d.png", i), []byte("png"))
+	}
+	fake.put("other/1.png", []byte("png"))
+
+	paths, err := storage.GetAllPaths(context.Background(), "creatives/")
+	require.NoError(t, err)
+	assert.Len(t, paths, 8)
+	assert.Equal(t, "creatives/0.png", paths[0])
+	assert.Equal(t, "creatives/7.png", paths[7])
+	assert.Equal(t, 3, fake.listRequests)
+}
+
+func TestFileStorage_DeleteAll(t *testing.T) {
+	fake, storage := newFakeS3(t, 1000)
+	for i := 0; i < 2500; i++ {
+		fake.put(fmt.Sprintf("creatives/%04d.png", i), nil)
+	}
+	fake.put("other/1.png", nil)
+	fake.failDelete["creatives/0001.png"] = true
+	fake.failDelete["creatives/2001.png"] = true
+
+	err := storage.DeleteAll(context.Background(), "creatives")
+	require.Error(t, err)
+	assert.Equal(t, []int{1000, 1000, 500}, fake.deleteSizes)
+
+	errs := multierr.Errors(err)
+	require.Len(t, errs, 2)
+	var objErr *ObjectError
+	require.ErrorAs(t, errs[0], &objErr)
+	assert.Equal(t, "creatives/0001.png", objErr.Key)
+	assert.Equal(t, "AccessDenied", objErr.Code)
+
+	assert.Equal(t, []string{"creatives/0001.png", "creatives/2001.png", "other/1.png"}, fake.keys())
+}
+
+func TestFileStorage_GetAll(t *testing.T) {
+	fake, storage := newFakeS3(t, 2)
+	for i := 0; i < 20; i++ {
+		fake.put(fmt.Sprintf("creatives/%02d.txt", i), []byte(fmt.Sprintf("%02d", i)))
+	}
+	fake.failGet["creatives/03.txt"] = true
+	fake.failGet["creatives/17.txt"] = true
+
+	core, logs := observer.New(zap.ErrorLevel)
+	ctx := cmnlogger.ToContext(context.Background(), zap.New(core))
+
+	files, err := storage.GetAll(ctx, "creatives")
+	require.NoError(t, err)
+	require.Len(t, files, 18)
+	for _, file := range files {
+		assert.NotEqual(t, "03.txt", file.Name())
+		data, err := file.Data()
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("creatives/%s.txt", data), file.Path())
+	}
+
+	var failed []string
+	for _, entry := range logs.FilterMessage("Failed to get file").All() {
+		failed = append(failed, entry.ContextMap()["key"].(string))
+	}
+	assert.ElementsMatch(t, []string{"creatives/03.txt", "creatives/17.txt"}, failed)
+}
@@ -0,0 +1,266 @@
+package s3
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec // etag isn't a security feature
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-faster/errors"
+)
+
+var ErrInvalidKey = errors.New("invalid key")
+
+var _ Storage = (*LocalStorage)(nil)
+
+// metaDir keeps metadata of objects, which a file system can't store, next to them.
+const metaDir = ".meta"
+
+// LocalStorage keeps objects as files in a directory. It's meant for development,
+// objects can be served with http.FileServer by the URL returned from PresignGet.
+type LocalStorage struct {
+	root    string
+	baseURL string
+}
+
+// NewLocalStorage creates storage in the root directory. BaseURL is the URL the directory is served at.
+func NewLocalStorage(root, baseURL string) (*LocalStorage, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil { //nolint:gomnd // directory permissions
+		return nil, errors.Wrap(err, "failed to create root directory")
+	}
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	return &LocalStorage{root: root, baseURL: baseURL}, nil
+}
+
+func (l *LocalStorage) Upload(_ context.Context, file *File, opts ...UploadOption) (string, error) {
+	options := newUploadOptions(opts)
+	key, err := localKey(file.key())
+	if err != nil {
+		return "", err
+	}
+	path, err := l.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:gomnd // directory permissions
+		return "", errors.Wrap(err, "failed to create directory")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create file")
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := md5.New() //nolint:gosec // etag isn't a security feature
+	size, err := io.Copy(io.MultiWriter(tmp, hash), options.body(file))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "failed to write file")
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return "", errors.Wrap(err, "failed to move file")
+	}
+
+	meta := newObjectMeta(key, nil, file, options, time.Now())
+	meta.Size = size
+	meta.ETag = fmt.Sprintf("%q", hex.EncodeToString(hash.Sum(nil)))
+	if err = l.writeMeta(key, meta); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+func (l *LocalStorage) Get(ctx context.Context, path string, opts ...GetOption) (*File, error) {
+	meta, err := l.Head(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	options := newGetOptions(opts)
+	start, end, err := options.bounds(meta.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := l.path(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open file")
+	}
+	if _, err = f.Seek(start, io.SeekStart); err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "failed to seek file")
+	}
+
+	body := struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(f, end-start), f}
+
+	return &File{
+		name:        baseName(meta.Key),
+		path:        meta.Key,
+		data:        options.body(body, end-start),
+		size:        end - start,
+		contentType: meta.ContentType,
+		etag:        meta.ETag,
+		metadata:    meta.Metadata,
+	}, nil
+}
+
+func (l *LocalStorage) Head(_ context.Context, key string) (*ObjectMeta, error) {
+	key, err := localKey(key)
+	if err != nil {
+		return nil, err
+	}
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+		return nil, errors.Wrap(ErrNotFound, key)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to stat file")
+	}
+
+	meta := ObjectMeta{}
+	data, err := os.ReadFile(l.metaPath(path))
+	switch {
+	case err == nil:
+		if err = json.Unmarshal(data, &meta); err != nil {
+			return nil, errors.Wrap(err, "failed to decode metadata")
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, errors.Wrap(err, "failed to read metadata")
+	}
+
+	// the file may be changed by hand, so the file system is the source of truth
+	meta.Key = key
+	meta.Size = info.Size()
+	meta.LastModified = info.ModTime()
+	return &meta, nil
+}
+
+// Delete removes the object. Deleting of a missing object isn't an error, as in S3.
+func (l *LocalStorage) Delete(_ context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	for _, name := range []string{path, l.metaPath(path)} {
+		if err = os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return errors.Wrap(err, "failed to delete file")
+		}
+	}
+	return nil
+}
+
+// List returns objects which keys start with prefix in lexicographical order, as in S3.
+func (l *LocalStorage) List(prefix string) *ObjectIterator {
+	return NewObjectIterator(func(ctx context.Context) ([]ObjectInfo, bool, error) {
+		var page []ObjectInfo
+		err := filepath.WalkDir(l.root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if d.Name() == metaDir {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasPrefix(d.Name(), ".upload-") {
+				return nil
+			}
+
+			rel, err := filepath.Rel(l.root, path)
+			if err != nil {
+				return err
+			}
+			key := filepath.ToSlash(rel)
+			if !strings.HasPrefix(key, prefix) {
+				return nil
+			}
+
+			meta, err := l.Head(ctx, key)
+			if err != nil {
+				return err
+			}
+			page = append(page, meta.ObjectInfo)
+			return nil
+		})
+		if err != nil {
+			return nil, false, errors.Wrap(err, "failed to list files")
+		}
+		sortObjects(page)
+		return page, false, nil
+	})
+}
+
+// PresignGet returns URL of the file at the base URL. It isn't signed and never expires actually.
+func (l *LocalStorage) PresignGet(ctx context.Context, path string, ttl time.Duration) (string, error) {
+	if _, err := l.Head(ctx, path); err != nil {
+		return "", err
+	}
+	return presignedURL(l.baseURL, path, time.Now().Add(ttl)), nil
+}
+
+// localKey normalizes the key the way List reports it, e.g. without the leading slash.
+// Keys escaping the root directory are rejected.
+func localKey(key string) (string, error) {
+	clean := strings.TrimPrefix(filepath.ToSlash(filepath.Clean("/"+filepath.FromSlash(key))), "/")
+	if clean == "" || strings.HasPrefix(clean, metaDir) {
+		return "", errors.Wrap(ErrInvalidKey, key)
+	}
+	return clean, nil
+}
+
+// path returns name of the file of the key.
+func (l *LocalStorage) path(key string) (string, error) {
+	clean, err := localKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.root, filepath.FromSlash(clean)), nil
+}
+
+func (l *LocalStorage) metaPath(path string) string {
+	rel, _ := filepath.Rel(l.root, path)
+	return filepath.Join(l.root, metaDir, rel+".json")
+}
+
+func (l *LocalStorage) writeMeta(key string, meta ObjectMeta) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode metadata")
+	}
+	name := l.metaPath(path)
+	if err = os.MkdirAll(filepath.Dir(name), 0o755); err != nil { //nolint:gomnd // directory permissions
+		return errors.Wrap(err, "failed to create metadata directory")
+	}
+	if err = os.WriteFile(name, data, 0o644); err != nil { //nolint:gomnd // file permissions
+		return errors.Wrap(err, "failed to write metadata")
+	}
+	return nil
+}
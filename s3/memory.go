@@ -0,0 +1,160 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5" //nolint:gosec // etag isn't a security feature
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-faster/errors"
+)
+
+var _ Storage = (*MemoryStorage)(nil)
+
+// MemoryStorage keeps objects in memory. It's meant for unit tests.
+type MemoryStorage struct {
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+	now     func() time.Time
+}
+
+type memoryObject struct {
+	data []byte
+	meta ObjectMeta
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		objects: make(map[string]memoryObject),
+		now:     time.Now,
+	}
+}
+
+func (m *MemoryStorage) Upload(_ context.Context, file *File, opts ...UploadOption) (string, error) {
+	options := newUploadOptions(opts)
+	data, err := io.ReadAll(options.body(file))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read file")
+	}
+
+	key := file.key()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = memoryObject{data: data, meta: newObjectMeta(key, data, file, options, m.now())}
+	return key, nil
+}
+
+func (m *MemoryStorage) Get(_ context.Context, path string, opts ...GetOption) (*File, error) {
+	obj, err := m.object(path)
+	if err != nil {
+		return nil, err
+	}
+
+	options := newGetOptions(opts)
+	start, end, err := options.bounds(int64(len(obj.data)))
+	if err != nil {
+		return nil, err
+	}
+	data := obj.data[start:end]
+
+	return &File{
+		name:        baseName(path),
+		path:        path,
+		data:        options.body(io.NopCloser(bytes.NewReader(data)), int64(len(data))),
+		size:        int64(len(data)),
+		contentType: obj.meta.ContentType,
+		etag:        obj.meta.ETag,
+		metadata:    maps.Clone(obj.meta.Metadata),
+	}, nil
+}
+
+func (m *MemoryStorage) Head(_ context.Context, key string) (*ObjectMeta, error) {
+	obj, err := m.object(key)
+	if err != nil {
+		return nil, err
+	}
+	// the stored metadata can't be changed through the returned one, as in S3
+	meta := obj.meta
+	meta.Metadata = maps.Clone(meta.Metadata)
+	return &meta, nil
+}
+
+// Delete removes the object. Deleting of a missing object isn't an error, as in S3.
+func (m *MemoryStorage) Delete(_ context.Context, path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, path)
+	return nil
+}
+
+// List returns objects which keys start with prefix in lexicographical order, as in S3.
+func (m *MemoryStorage) List(prefix string) *ObjectIterator {
+	return NewObjectIterator(func(context.Context) ([]ObjectInfo, bool, error) {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+
+		var page []ObjectInfo
+		for key, obj := range m.objects {
+			if strings.HasPrefix(key, prefix) {
+				page = append(page, obj.meta.ObjectInfo)
+			}
+		}
+		sortObjects(page)
+		return page, false, nil
+	})
+}
+
+// PresignGet returns fake URL, the object can't be downloaded with it.
+func (m *MemoryStorage) PresignGet(_ context.Context, path string, ttl time.Duration) (string, error) {
+	if _, err := m.object(path); err != nil {
+		return "", err
+	}
+	return presignedURL("memory://", path, m.now().Add(ttl)), nil
+}
+
+func (m *MemoryStorage) object(key string) (memoryObject, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	obj, ok := m.objects[key]
+	if !ok {
+		return memoryObject{}, errors.Wrap(ErrNotFound, key)
+	}
+	return obj, nil
+}
+
+func newObjectMeta(key string, data []byte, file *File, options uploadOptions, modified time.Time) ObjectMeta {
+	contentType := options.contentType
+	if contentType == "" {
+		contentType = file.contentType
+	}
+	sum := md5.Sum(data) //nolint:gosec // etag isn't a security feature
+	return ObjectMeta{
+		ObjectInfo: ObjectInfo{
+			Key:          key,
+			Size:         int64(len(data)),
+			LastModified: modified,
+			ETag:         fmt.Sprintf("%q", hex.EncodeToString(sum[:])),
+		},
+		ContentType:  contentType,
+		CacheControl: options.cacheControl,
+		Metadata:     maps.Clone(options.metadata),
+	}
+}
+
+func sortObjects(objects []ObjectInfo) {
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Key < objects[j].Key
+	})
+}
+
+func presignedURL(base, path string, expires time.Time) string {
+	return base + path + "?" + url.Values{"expires": {fmt.Sprint(expires.Unix())}}.Encode()
+}
@@ -0,0 +1,185 @@
+package s3
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/go-faster/errors"
+)
+
+type uploadOptions struct {
+	partSize     int64
+	concurrency  int
+	progress     ProgressFunc
+	contentType  string
+	cacheControl string
+	metadata     map[string]string
+	encryption   *ServerSideEncryption
+}
+
+type UploadOption func(*uploadOptions)
+
+func newUploadOptions(opts []UploadOption) uploadOptions {
+	var options uploadOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// body wraps the content of the file into progress reporting reader if needed.
+func (o *uploadOptions) body(file *File) io.Reader {
+	if o.progress != nil {
+		return newProgressReader(file.data, file.size, o.progress)
+	}
+	return file.data
+}
+
+// WithPartSize sets size of parts of multipart upload. Objects smaller than it are uploaded with a single request.
+func WithPartSize(size int64) UploadOption {
+	return func(o *uploadOptions) {
+		o.partSize = size
+	}
+}
+
+// WithConcurrency sets number of parts uploaded in parallel.
+func WithConcurrency(n int) UploadOption {
+	return func(o *uploadOptions) {
+		o.concurrency = n
+	}
+}
+
+func WithUploadProgress(progress ProgressFunc) UploadOption {
+	return func(o *uploadOptions) {
+		o.progress = progress
+	}
+}
+
+// WithContentType overrides content type of the uploaded file.
+func WithContentType(contentType string) UploadOption {
+	return func(o *uploadOptions) {
+		o.contentType = contentType
+	}
+}
+
+func WithCacheControl(cacheControl string) UploadOption {
+	return func(o *uploadOptions) {
+		o.cacheControl = cacheControl
+	}
+}
+
+// WithMetadata sets user metadata stored with the object as x-amz-meta-* headers.
+func WithMetadata(metadata map[string]string) UploadOption {
+	return func(o *uploadOptions) {
+		o.metadata = metadata
+	}
+}
+
+// WithServerSideEncryption overrides encryption of the storage config for the uploaded object.
+func WithServerSideEncryption(encryption ServerSideEncryption) UploadOption {
+	return func(o *uploadOptions) {
+		o.encryption = &encryption
+	}
+}
+
+type getOptions struct {
+	offset      int64
+	length      int64
+	progress    ProgressFunc
+	customerKey []byte
+}
+
+// rangeHeader returns value of the Range header or nil if the whole object is requested.
+func (o *getOptions) rangeHeader() *string {
+	if o.offset == 0 && o.length <= 0 {
+		return nil
+	}
+	if o.length <= 0 {
+		return aws.String(fmt.Sprintf("bytes=%d-", o.offset))
+	}
+	return aws.String(fmt.Sprintf("bytes=%d-%d", o.offset, o.offset+o.length-1))
+}
+
+type GetOption func(*getOptions)
+
+func newGetOptions(opts []GetOption) getOptions {
+	var options getOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// bounds returns the requested range of the object of the given size as [start, end).
+func (o *getOptions) bounds(size int64) (int64, int64, error) {
+	if o.offset < 0 || (o.offset > 0 && o.offset >= size) {
+		return 0, 0, errors.Wrap(ErrInvalidRange, fmt.Sprintf("offset %d of object of size %d", o.offset, size))
+	}
+	end := size
+	if o.length > 0 && o.offset+o.length < size {
+		end = o.offset + o.length
+	}
+	return o.offset, end, nil
+}
+
+// body wraps the object content into progress reporting reader if needed.
+func (o *getOptions) body(r io.Reader, size int64) io.Reader {
+	if o.progress != nil {
+		return newProgressReader(r, size, o.progress)
+	}
+	return r
+}
+
+// WithRange requests length bytes starting at offset. Non-positive length means up to the end of the object.
+func WithRange(offset, length int64) GetOption {
+	return func(o *getOptions) {
+		o.offset = offset
+		o.length = length
+	}
+}
+
+// WithCustomerKey sets SSE-C key of the object, if it differs from the one of the storage config.
+func WithCustomerKey(key []byte) GetOption {
+	return func(o *getOptions) {
+		o.customerKey = key
+	}
+}
+
+func WithDownloadProgress(progress ProgressFunc) GetOption {
+	return func(o *getOptions) {
+		o.progress = progress
+	}
+}
+
+type batchOptions struct {
+	workers  int
+	progress ProgressFunc
+}
+
+// BatchOption configures operations over all objects under a prefix.
+type BatchOption func(*batchOptions)
+
+func newBatchOptions(opts []BatchOption) batchOptions {
+	options := batchOptions{workers: defaultWorkers}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// WithWorkers sets number of objects processed in parallel.
+func WithWorkers(n int) BatchOption {
+	return func(o *batchOptions) {
+		if n > 0 {
+			o.workers = n
+		}
+	}
+}
+
+// WithBatchProgress reports number of processed objects, including failed ones, and total number of objects.
+func WithBatchProgress(progress ProgressFunc) BatchOption {
+	return func(o *batchOptions) {
+		o.progress = progress
+	}
+}
@@ -0,0 +1,26 @@
+package s3
+
+import "sync"
+
+// parallel calls fn for every index in [0, n) using at most workers goroutines.
+func parallel(n, workers int, fn func(i int)) {
+	var (
+		jobs = make(chan int)
+		wg   sync.WaitGroup
+	)
+	for w := 0; w < min(workers, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
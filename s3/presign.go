@@ -0,0 +1,112 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/go-faster/errors"
+)
+
+// PresignedRequest is a request signed in advance, which can be sent without credentials until it expires.
+// Header contains headers included into the signature, the client must send them as is.
+type PresignedRequest struct {
+	URL    string
+	Method string
+	Header http.Header
+}
+
+// PresignedPost is a form upload, Values must be sent as form fields along with the file field.
+type PresignedPost struct {
+	URL    string
+	Values map[string]string
+}
+
+// PresignGet returns URL which allows to download the object without credentials until ttl expires.
+func (f *FileStorage) PresignGet(ctx context.Context, path string, ttl time.Duration) (string, error) {
+	req, err := f.presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(f.bucket),
+		Key:    aws.String(path),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to presign get object")
+	}
+	return req.URL, nil
+}
+
+// PresignPut signs upload of the object with exactly the given content type and size.
+// Empty content type and non-positive size leave the corresponding header unrestricted.
+func (f *FileStorage) PresignPut(
+	ctx context.Context,
+	path string,
+	ttl time.Duration,
+	contentType string,
+	size int64,
+) (*PresignedRequest, error) {
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(f.bucket),
+		Key:    aws.String(path),
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	if size > 0 {
+		input.ContentLength = aws.Int64(size)
+	}
+
+	req, err := f.presigner.PresignPutObject(ctx, input, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to presign put object")
+	}
+	return &PresignedRequest{URL: req.URL, Method: req.Method, Header: req.SignedHeader}, nil
+}
+
+// PresignPost signs form upload of the object, which size must be within [minSize, maxSize].
+// Unlike PresignPut, it allows any size in the range, so it suits uploads from a browser.
+func (f *FileStorage) PresignPost(
+	ctx context.Context,
+	path string,
+	ttl time.Duration,
+	contentType string,
+	minSize, maxSize int64,
+) (*PresignedPost, error) {
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(f.bucket),
+		Key:    aws.String(path),
+	}
+	conditions := []interface{}{
+		[]interface{}{"content-length-range", minSize, maxSize},
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+		conditions = append(conditions, map[string]string{"Content-Type": contentType})
+	}
+
+	req, err := f.presigner.PresignPostObject(ctx, input, func(o *s3.PresignPostOptions) {
+		o.Expires = ttl
+		o.Conditions = conditions
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to presign post object")
+	}
+
+	values := req.Values
+	if contentType != "" {
+		values["Content-Type"] = contentType
+	}
+	return &PresignedPost{URL: req.URL, Values: values}, nil
+}
+
+// PresignDelete returns URL which allows to delete the object with DELETE request until ttl expires.
+func (f *FileStorage) PresignDelete(ctx context.Context, path string, ttl time.Duration) (string, error) {
+	req, err := f.presigner.PresignDeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(f.bucket),
+		Key:    aws.String(path),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to presign delete object")
+	}
+	return req.URL, nil
+}
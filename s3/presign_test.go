@@ -0,0 +1,52 @@
+package s3
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStorage(t *testing.T) *FileStorage {
+	t.Helper()
+	storage, err := New(&Config{
+		Bucket:    "bucket",
+		Region:    "us-east-1",
+		AccessKey: "access",
+		SecretKey: "secret",
+		Url:       "http://s3.local",
+	})
+	require.NoError(t, err)
+	return storage
+}
+
+func TestFileStorage_Presign(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestStorage(t)
+
+	get, err := storage.PresignGet(ctx, "creatives/1.png", time.Minute)
+	require.NoError(t, err)
+	u, err := url.Parse(get)
+	require.NoError(t, err)
+	assert.Equal(t, "60", u.Query().Get("X-Amz-Expires"))
+	assert.NotEmpty(t, u.Query().Get("X-Amz-Signature"))
+
+	put, err := storage.PresignPut(ctx, "creatives/1.png", time.Minute, "image/png", 1024)
+	require.NoError(t, err)
+	assert.Equal(t, "PUT", put.Method)
+	assert.Equal(t, "image/png", put.Header.Get("Content-Type"))
+	assert.Equal(t, "1024", put.Header.Get("Content-Length"))
+
+	post, err := storage.PresignPost(ctx, "creatives/1.png", time.Minute, "image/png", 1, 1024)
+	require.NoError(t, err)
+	assert.Equal(t, "creatives/1.png", post.Values["key"])
+	assert.Equal(t, "image/png", post.Values["Content-Type"])
+	assert.NotEmpty(t, post.Values["policy"])
+
+	del, err := storage.PresignDelete(ctx, "creatives/1.png", time.Minute)
+	require.NoError(t, err)
+	assert.Contains(t, del, "creatives/1.png")
+}
@@ -1,25 +1,51 @@
 package s3
 
 import (
+	"bytes"
 	"context"
-	"fmt"
 	"strings"
 
 	cmnlogger "github.com/Justksenia/common/logger"
 	"github.com/Justksenia/common/tracer"
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
 	"github.com/go-faster/errors"
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
 )
 
+var (
+	ErrNotFound     = errors.New("object not found")
+	ErrInvalidRange = errors.New("invalid range")
+)
+
+var _ Storage = (*FileStorage)(nil)
+
+const (
+	// deleteBatchSize is the maximum number of keys DeleteObjects accepts.
+	deleteBatchSize = 1000
+	defaultWorkers  = 8
+)
+
 type FileStorage struct {
-	client *s3.Client
-	bucket string
+	client    *s3.Client
+	uploader  *manager.Uploader
+	presigner *s3.PresignClient
+	bucket    string
+	sse       ServerSideEncryption
+	// multipartCopyThreshold and minCopyPartSize are lowered in tests to copy small objects in parts.
+	multipartCopyThreshold int64
+	minCopyPartSize        int64
 }
 
 func New(cfg *Config) (*FileStorage, error) {
+	if err := cfg.Encryption.Validate(); err != nil {
+		return nil, err
+	}
+
 	customResolver := aws.EndpointResolverWithOptionsFunc(func(service, region string, options ...interface{}) (aws.Endpoint, error) {
 		return aws.Endpoint{
 			PartitionID:   cfg.PartitionID,
@@ -38,16 +64,70 @@ func New(cfg *Config) (*FileStorage, error) {
 		Region:                      cfg.Region,
 		EndpointResolverWithOptions: customResolver,
 		Credentials:                 credentialsProvider,
+	}, func(o *s3.Options) {
+		o.UsePathStyle = cfg.UsePathStyle
 	})
-	return &FileStorage{client: client, bucket: cfg.Bucket}, nil
+	return &FileStorage{
+		client:    client,
+		uploader:  manager.NewUploader(client),
+		presigner: s3.NewPresignClient(client),
+		bucket:    cfg.Bucket,
+		sse:       cfg.Encryption,
+
+		multipartCopyThreshold: maxCopyObjectSize,
+		minCopyPartSize:        defaultCopyPartSize,
+	}, nil
 }
 
-func (f *FileStorage) Upload(ctx context.Context, file *File) (string, error) {
-	key := fmt.Sprintf("%s/%s", file.path, file.name)
-	_, err := f.client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket: aws.String(f.bucket),
-		Key:    aws.String(key),
-		Body:   file.data,
+// Upload streams the file to the storage. Files bigger than the part size are uploaded in parts,
+// so the content is never held in memory entirely.
+func (f *FileStorage) Upload(ctx context.Context, file *File, opts ...UploadOption) (string, error) {
+	options := newUploadOptions(opts)
+
+	sse := f.sse
+	if options.encryption != nil {
+		if err := options.encryption.Validate(); err != nil {
+			return "", err
+		}
+		sse = *options.encryption
+	}
+	headers := sse.headers()
+
+	key := file.key()
+	input := &s3.PutObjectInput{
+		Bucket:               aws.String(f.bucket),
+		Key:                  aws.String(key),
+		Body:                 options.body(file),
+		ServerSideEncryption: headers.encryption,
+		SSEKMSKeyId:          headers.kmsKeyID,
+		SSECustomerAlgorithm: headers.algorithm,
+		SSECustomerKey:       headers.customerKey,
+		SSECustomerKeyMD5:    headers.keyMD5,
+	}
+	if file.size != UnknownSize {
+		// the uploader doesn't have to buffer the stream to find out its size
+		input.ContentLength = aws.Int64(file.size)
+	}
+	if options.contentType == "" {
+		options.contentType = file.contentType
+	}
+	if options.contentType != "" {
+		input.ContentType = aws.String(options.contentType)
+	}
+	if options.cacheControl != "" {
+		input.CacheControl = aws.String(options.cacheControl)
+	}
+	if len(options.metadata) > 0 {
+		input.Metadata = options.metadata
+	}
+
+	_, err := f.uploader.Upload(ctx, input, func(u *manager.Uploader) {
+		if options.partSize > 0 {
+			u.PartSize = options.partSize
+		}
+		if options.concurrency > 0 {
+			u.Concurrency = options.concurrency
+		}
 	})
 	if err != nil {
 		return key, errors.Wrap(err, "failed to upload file")
@@ -56,20 +136,19 @@ func (f *FileStorage) Upload(ctx context.Context, file *File) (string, error) {
 }
 
 func (f *FileStorage) GetAllPaths(ctx context.Context, pattern string) ([]string, error) {
-	resp, err := f.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
-		Bucket: aws.String(f.bucket),
-		Prefix: aws.String(pattern),
-	})
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to list objects")
+	var paths []string
+	it := f.List(pattern)
+	for it.Next(ctx) {
+		paths = append(paths, it.Object().Key)
 	}
-	paths := make([]string, 0, len(resp.Contents))
-	for _, obj := range resp.Contents {
-		paths = append(paths, *obj.Key)
+	if err := it.Err(); err != nil {
+		return nil, err
 	}
 	return paths, nil
 }
 
+// GetAll downloads all objects under the path into memory. Objects are fetched by a bounded pool of workers,
+// the ones which failed to download are logged and skipped.
 func (f *FileStorage) GetAll(ctx context.Context, path string) ([]File, error) {
 	if !strings.HasSuffix(path, "/") {
 		path += "/"
@@ -77,46 +156,121 @@ func (f *FileStorage) GetAll(ctx context.Context, path string) ([]File, error) {
 
 	logger := cmnlogger.FromContext(ctx).With(zap.String("Method", tracer.AutoFillName()))
 
-	resp, err := f.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
-		Bucket: aws.String(f.bucket),
-		Prefix: aws.String(path),
-	})
+	keys, err := f.GetAllPaths(ctx, path)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to list objects")
+		return nil, err
 	}
 
-	files := make([]File, 0, len(resp.Contents))
-
-	for _, obj := range resp.Contents {
-		file, inErr := f.Get(ctx, *obj.Key)
+	files := make([]*File, len(keys))
+	parallel(len(keys), defaultWorkers, func(i int) {
+		file, inErr := f.download(ctx, keys[i])
 		if inErr != nil {
-			logger.Error("Failed to get file", zap.String("key", *obj.Key), zap.Error(inErr))
-			continue
+			logger.Error("Failed to get file", zap.String("key", keys[i]), zap.Error(inErr))
+			return
+		}
+		files[i] = file
+	})
+
+	result := make([]File, 0, len(files))
+	for _, file := range files {
+		if file != nil {
+			result = append(result, *file)
 		}
-		files = append(files, *file)
 	}
-	return files, nil
+	return result, nil
 }
 
-func (f *FileStorage) Get(ctx context.Context, path string) (*File, error) {
-	resp, err := f.client.GetObject(ctx, &s3.GetObjectInput{
-		Bucket: aws.String(f.bucket),
-		Key:    aws.String(path),
+// download reads the whole object, so the connection is released before the file is handed out.
+func (f *FileStorage) download(ctx context.Context, path string) (*File, error) {
+	file, err := f.Get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := file.Data()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read object")
+	}
+	file.data = bytes.NewReader(data)
+	file.size = int64(len(data))
+	return file, nil
+}
+
+// Head returns metadata of the object without downloading it. ErrNotFound is returned for missing objects.
+func (f *FileStorage) Head(ctx context.Context, key string) (*ObjectMeta, error) {
+	headers := f.sse.readHeaders()
+	resp, err := f.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:               aws.String(f.bucket),
+		Key:                  aws.String(key),
+		SSECustomerAlgorithm: headers.algorithm,
+		SSECustomerKey:       headers.customerKey,
+		SSECustomerKeyMD5:    headers.keyMD5,
 	})
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return nil, errors.Wrap(ErrNotFound, key)
+		}
+		return nil, errors.Wrap(err, "failed to head object")
+	}
+
+	return &ObjectMeta{
+		ObjectInfo: ObjectInfo{
+			Key:          key,
+			Size:         aws.ToInt64(resp.ContentLength),
+			LastModified: aws.ToTime(resp.LastModified),
+			ETag:         aws.ToString(resp.ETag),
+		},
+		ContentType:  aws.ToString(resp.ContentType),
+		CacheControl: aws.ToString(resp.CacheControl),
+		Metadata:     resp.Metadata,
+	}, nil
+}
 
+// Get opens the object for reading. The returned file streams the body and must be closed.
+func (f *FileStorage) Get(ctx context.Context, path string, opts ...GetOption) (*File, error) {
+	options := newGetOptions(opts)
+
+	sse := f.sse
+	if options.customerKey != nil {
+		sse = ServerSideEncryption{Mode: SSEC, CustomerKey: options.customerKey}
+	}
+	headers := sse.readHeaders()
+
+	resp, err := f.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket:               aws.String(f.bucket),
+		Key:                  aws.String(path),
+		Range:                options.rangeHeader(),
+		SSECustomerAlgorithm: headers.algorithm,
+		SSECustomerKey:       headers.customerKey,
+		SSECustomerKeyMD5:    headers.keyMD5,
+	})
 	if err != nil {
+		var (
+			noSuchKey *types.NoSuchKey
+			apiErr    smithy.APIError
+		)
+		if errors.As(err, &noSuchKey) {
+			return nil, errors.Wrap(ErrNotFound, path)
+		}
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "InvalidRange" {
+			return nil, errors.Wrap(ErrInvalidRange, path)
+		}
 		return nil, errors.Wrap(err, "failed to get object")
 	}
-	fileName := path
-	splitted := strings.Split(path, "/")
-	if len(splitted) > 0 {
-		fileName = splitted[len(splitted)-1]
+
+	size := UnknownSize
+	if resp.ContentLength != nil {
+		size = *resp.ContentLength
 	}
 
 	return &File{
-		name: fileName,
-		path: path,
-		data: resp.Body,
+		name:        baseName(path),
+		path:        path,
+		data:        options.body(resp.Body, size),
+		size:        size,
+		contentType: aws.ToString(resp.ContentType),
+		etag:        aws.ToString(resp.ETag),
+		metadata:    resp.Metadata,
 	}, nil
 }
 
@@ -131,28 +285,56 @@ func (f *FileStorage) Delete(ctx context.Context, path string) error {
 	return nil
 }
 
+// DeleteAll deletes all objects under the path in batches. Objects which failed to be deleted
+// are reported as *ObjectError combined with multierr.
 func (f *FileStorage) DeleteAll(ctx context.Context, path string) error {
-	objects, err := f.GetAll(ctx, path)
-	if err != nil {
-		return errors.Wrap(err, "failed to get objects")
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
 	}
 
-	deleteObjectsId := make([]types.ObjectIdentifier, 0, len(objects))
-	for _, obj := range objects {
-		deleteObjectsId = append(deleteObjectsId, types.ObjectIdentifier{
-			Key: &obj.path,
+	var (
+		batch  = make([]types.ObjectIdentifier, 0, deleteBatchSize)
+		result error
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		resp, err := f.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(f.bucket),
+			Delete: &types.Delete{
+				Objects: batch,
+				Quiet:   aws.Bool(true),
+			},
 		})
+		if err != nil {
+			return errors.Wrap(err, "failed to delete objects")
+		}
+		for _, e := range resp.Errors {
+			result = multierr.Append(result, &ObjectError{
+				Key:  aws.ToString(e.Key),
+				Code: aws.ToString(e.Code),
+				Err:  errors.New(aws.ToString(e.Message)),
+			})
+		}
+		batch = batch[:0]
+		return nil
 	}
 
-	_, err = f.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
-		Bucket: aws.String(f.bucket),
-		Delete: &types.Delete{
-			Objects: deleteObjectsId,
-		},
-	})
-
-	if err != nil {
-		return errors.Wrap(err, "failed to delete objects")
+	it := f.List(path)
+	for it.Next(ctx) {
+		batch = append(batch, types.ObjectIdentifier{Key: aws.String(it.Object().Key)})
+		if len(batch) == deleteBatchSize {
+			if err := flush(); err != nil {
+				return multierr.Append(result, err)
+			}
+		}
 	}
-	return nil
+	if err := it.Err(); err != nil {
+		return multierr.Append(result, err)
+	}
+	if err := flush(); err != nil {
+		return multierr.Append(result, err)
+	}
+	return result
 }
@@ -0,0 +1,19 @@
+package s3
+
+import (
+	"context"
+	"time"
+)
+
+// Storage is an object storage. FileStorage keeps objects in S3, LocalStorage in a directory
+// for development and MemoryStorage in memory for unit tests.
+type Storage interface {
+	// Upload stores the file under path/name and returns its key.
+	Upload(ctx context.Context, file *File, opts ...UploadOption) (string, error)
+	// Get opens the object for reading, the returned file must be closed.
+	Get(ctx context.Context, path string, opts ...GetOption) (*File, error)
+	Head(ctx context.Context, key string) (*ObjectMeta, error)
+	Delete(ctx context.Context, path string) error
+	List(prefix string) *ObjectIterator
+	PresignGet(ctx context.Context, path string, ttl time.Duration) (string, error)
+}
@@ -0,0 +1,162 @@
+package s3
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Justksenia/common/containers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage(t *testing.T) {
+	local, err := NewLocalStorage(t.TempDir(), "http://localhost/files")
+	require.NoError(t, err)
+
+	storages := map[string]Storage{
+		"memory": NewMemoryStorage(),
+		"local":  local,
+	}
+	for name, storage := range storages {
+		t.Run(name, func(t *testing.T) {
+			testStorage(t, storage)
+		})
+	}
+}
+
+// newMinioStorage starts MinIO container and returns FileStorage connected to it.
+func newMinioStorage(t *testing.T) *FileStorage {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	minio, err := containers.NewMinio(ctx, containers.MinioConf{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = minio.Container.Terminate(context.Background()) })
+
+	storage, err := New(&Config{
+		Bucket:       minio.Bucket,
+		Region:       "us-east-1",
+		AccessKey:    minio.AccessKey,
+		SecretKey:    minio.SecretKey,
+		Url:          minio.External,
+		UsePathStyle: true,
+	})
+	require.NoError(t, err)
+	return storage
+}
+
+func TestFileStorage_Minio(t *testing.T) {
+	testStorage(t, newMinioStorage(t))
+}
+
+func TestFileStorage_GetNotFound(t *testing.T) {
+	_, storage := newFakeS3(t, 1000)
+
+	_, err := storage.Get(context.Background(), "creatives/missing.png")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func testStorage(t *testing.T, storage Storage) {
+	ctx := context.Background()
+
+	var (
+		progress int64
+		metadata = map[string]string{"owner": "42"}
+	)
+	key, err := storage.Upload(ctx,
+		NewStreamFile("1.txt", "creatives/a", strings.NewReader("hello world"), UnknownSize),
+		WithContentType("text/plain"),
+		WithCacheControl("no-cache"),
+		WithMetadata(metadata),
+		WithUploadProgress(func(transferred, _ int64) { progress = transferred }),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "creatives/a/1.txt", key)
+	assert.Equal(t, int64(11), progress)
+
+	_, err = storage.Upload(ctx, NewFile("2.txt", "creatives/b", []byte("second")))
+	require.NoError(t, err)
+	_, err = storage.Upload(ctx, NewFile("3.txt", "other", []byte("third")))
+	require.NoError(t, err)
+
+	meta, err := storage.Head(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), meta.Size)
+	assert.Equal(t, "text/plain", meta.ContentType)
+	assert.Equal(t, "no-cache", meta.CacheControl)
+	assert.Equal(t, map[string]string{"owner": "42"}, meta.Metadata)
+	assert.Equal(t, `"5eb63bbbe01eeed093cb22bb8f5acdc3"`, meta.ETag)
+
+	// the stored metadata doesn't change with the maps it was given in and returned in
+	metadata["owner"] = "43"
+	meta.Metadata["owner"] = "44"
+	stored, err := storage.Head(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"owner": "42"}, stored.Metadata)
+
+	file, err := storage.Get(ctx, key)
+	require.NoError(t, err)
+	data, err := file.Data()
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(data))
+	assert.Equal(t, "text/plain", file.ContentType())
+	assert.Equal(t, meta.ETag, file.ETag())
+
+	file, err = storage.Get(ctx, key, WithRange(6, 3))
+	require.NoError(t, err)
+	data, err = file.Data()
+	require.NoError(t, err)
+	assert.Equal(t, "wor", string(data))
+
+	_, err = storage.Get(ctx, key, WithRange(20, 0))
+	assert.ErrorIs(t, err, ErrInvalidRange)
+
+	var keys []string
+	it := storage.List("creatives/")
+	for it.Next(ctx) {
+		keys = append(keys, it.Object().Key)
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, []string{"creatives/a/1.txt", "creatives/b/2.txt"}, keys)
+
+	url, err := storage.PresignGet(ctx, key, time.Minute)
+	require.NoError(t, err)
+	assert.Contains(t, url, key)
+
+	require.NoError(t, storage.Delete(ctx, key))
+	require.NoError(t, storage.Delete(ctx, key))
+	_, err = storage.Head(ctx, key)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = storage.Get(ctx, key)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestLocalStorage_InvalidKey(t *testing.T) {
+	storage, err := NewLocalStorage(t.TempDir(), "http://localhost")
+	require.NoError(t, err)
+
+	_, err = storage.Upload(context.Background(), NewFile("x.json", ".meta", nil))
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestLocalStorage_LeadingSlash(t *testing.T) {
+	ctx := context.Background()
+	storage, err := NewLocalStorage(t.TempDir(), "http://localhost")
+	require.NoError(t, err)
+
+	key, err := storage.Upload(ctx, NewFile("1.txt", "/creatives", []byte("hello")))
+	require.NoError(t, err)
+	assert.Equal(t, "creatives/1.txt", key)
+
+	it := storage.List("")
+	require.True(t, it.Next(ctx))
+	assert.Equal(t, key, it.Object().Key)
+
+	meta, err := storage.Head(ctx, "/creatives/1.txt")
+	require.NoError(t, err)
+	assert.Equal(t, key, meta.Key)
+}
@@ -0,0 +1,54 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-faster/errors"
+)
+
+// TopicAdmin is the subset of a broker admin client needed to manage topics.
+// DescribeTopic must return ErrTopicNotFound when the topic does not exist.
+type TopicAdmin interface {
+	DescribeTopic(ctx context.Context, name string) (TopicConfig, error)
+	CreateTopic(ctx context.Context, name string, config TopicConfig) error
+}
+
+// EnsureTopics creates missing topics of the registry and verifies that existing ones match their config.
+func EnsureTopics(ctx context.Context, admin TopicAdmin, registry *Registry) error {
+	for _, topic := range registry.Topics() {
+		if err := ensureTopic(ctx, admin, topic); err != nil {
+			return errors.Wrap(err, topic.Name())
+		}
+	}
+	return nil
+}
+
+func ensureTopic(ctx context.Context, admin TopicAdmin, topic Descriptor) error {
+	expected := topic.Config()
+
+	actual, err := admin.DescribeTopic(ctx, topic.Name())
+	if errors.Is(err, ErrTopicNotFound) {
+		if err = admin.CreateTopic(ctx, topic.Name(), expected); err != nil {
+			return errors.Wrap(err, "create topic")
+		}
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "describe topic")
+	}
+
+	if actual.Partitions != expected.Partitions {
+		return errors.Wrap(
+			ErrTopicMismatch,
+			fmt.Sprintf("expected %d partitions, got %d", expected.Partitions, actual.Partitions),
+		)
+	}
+	if expected.Retention != 0 && actual.Retention != expected.Retention {
+		return errors.Wrap(
+			ErrTopicMismatch,
+			fmt.Sprintf("expected retention %s, got %s", expected.Retention, actual.Retention),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package notification
+
+import (
+	"time"
+
+	"github.com/Justksenia/common/schema/kafka/gen"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func newEvent(eventType gen.NotificationEventType, at time.Time) *gen.TelegramNotificationEvent {
+	return &gen.TelegramNotificationEvent{
+		EventType:     eventType,
+		EventDatetime: timestamppb.New(at),
+	}
+}
+
+func NewBalanceReplenishment(at time.Time, e *gen.BalanceReplenishmentEvent) *gen.TelegramNotificationEvent {
+	event := newEvent(gen.NotificationEventType_BALANCE_REPLENISHMENT_EVENT_TYPE, at)
+	event.Event = &gen.TelegramNotificationEvent_BalanceReplenishmentEvent{BalanceReplenishmentEvent: e}
+	return event
+}
+
+func NewBalanceWithdrawal(at time.Time, e *gen.BalanceWithdrawalEvent) *gen.TelegramNotificationEvent {
+	event := newEvent(gen.NotificationEventType_BALANCE_WITHDRAWAL_EVENT_TYPE, at)
+	event.Event = &gen.TelegramNotificationEvent_BalanceWithdrawalEvent{BalanceWithdrawalEvent: e}
+	return event
+}
+
+func NewPostRequest(at time.Time, e *gen.PostRequestEvent) *gen.TelegramNotificationEvent {
+	event := newEvent(gen.NotificationEventType_POST_REQUEST_EVENT_TYPE, at)
+	event.Event = &gen.TelegramNotificationEvent_PostRequestEvent{PostRequestEvent: e}
+	return event
+}
+
+func NewChangePurchaseStatus(at time.Time, e *gen.ChangePurchaseStatusEvent) *gen.TelegramNotificationEvent {
+	event := newEvent(gen.NotificationEventType_CHANGE_PURCHASE_STATUS_EVENT_TYPE, at)
+	event.Event = &gen.TelegramNotificationEvent_ChangePurchaseStatusEvent{ChangePurchaseStatusEvent: e}
+	return event
+}
+
+// NewSendAdvRequestToManager reuses PostRequestEvent as payload, but puts it in its own oneof field.
+func NewSendAdvRequestToManager(at time.Time, e *gen.PostRequestEvent) *gen.TelegramNotificationEvent {
+	event := newEvent(gen.NotificationEventType_SEND_ADV_REQUEST_TO_MANAGER_EVENT_TYPE, at)
+	event.Event = &gen.TelegramNotificationEvent_SendAdvRequestToManagerEvent{SendAdvRequestToManagerEvent: e}
+	return event
+}
+
+func NewSendCreativeToUser(at time.Time, e *gen.SendCreativeToUserEvent) *gen.TelegramNotificationEvent {
+	event := newEvent(gen.NotificationEventType_SEND_CREATIVE_TO_USER_EVENT_TYPE, at)
+	event.Event = &gen.TelegramNotificationEvent_SendCreativeToUserEvent{SendCreativeToUserEvent: e}
+	return event
+}
@@ -0,0 +1,43 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/Justksenia/common/schema/kafka/gen"
+	"github.com/go-faster/errors"
+)
+
+// Handlers has a method per notification event type,
+// so adding a new event type breaks compilation of every consumer until it is handled.
+type Handlers interface {
+	BalanceReplenishment(ctx context.Context, e *gen.BalanceReplenishmentEvent) error
+	BalanceWithdrawal(ctx context.Context, e *gen.BalanceWithdrawalEvent) error
+	PostRequest(ctx context.Context, e *gen.PostRequestEvent) error
+	ChangePurchaseStatus(ctx context.Context, e *gen.ChangePurchaseStatusEvent) error
+	SendAdvRequestToManager(ctx context.Context, e *gen.PostRequestEvent) error
+	SendCreativeToUser(ctx context.Context, e *gen.SendCreativeToUserEvent) error
+}
+
+// Handle validates the event and dispatches its payload to the matching handler.
+func Handle(ctx context.Context, event *gen.TelegramNotificationEvent, handlers Handlers) error {
+	if err := Validate(event); err != nil {
+		return err
+	}
+
+	switch payload := event.GetEvent().(type) {
+	case *gen.TelegramNotificationEvent_BalanceReplenishmentEvent:
+		return handlers.BalanceReplenishment(ctx, payload.BalanceReplenishmentEvent)
+	case *gen.TelegramNotificationEvent_BalanceWithdrawalEvent:
+		return handlers.BalanceWithdrawal(ctx, payload.BalanceWithdrawalEvent)
+	case *gen.TelegramNotificationEvent_PostRequestEvent:
+		return handlers.PostRequest(ctx, payload.PostRequestEvent)
+	case *gen.TelegramNotificationEvent_ChangePurchaseStatusEvent:
+		return handlers.ChangePurchaseStatus(ctx, payload.ChangePurchaseStatusEvent)
+	case *gen.TelegramNotificationEvent_SendAdvRequestToManagerEvent:
+		return handlers.SendAdvRequestToManager(ctx, payload.SendAdvRequestToManagerEvent)
+	case *gen.TelegramNotificationEvent_SendCreativeToUserEvent:
+		return handlers.SendCreativeToUser(ctx, payload.SendCreativeToUserEvent)
+	default:
+		return errors.Wrap(ErrUnknownPayload, "handle")
+	}
+}
@@ -0,0 +1,136 @@
+package notification
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Justksenia/common/schema/kafka/gen"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestValidate(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name        string
+		event       *gen.TelegramNotificationEvent
+		expectedErr error
+	}{
+		{
+			name:  "valid balance replenishment",
+			event: NewBalanceReplenishment(now, &gen.BalanceReplenishmentEvent{Sum: "100"}),
+		},
+		{
+			name:  "valid send adv request to manager",
+			event: NewSendAdvRequestToManager(now, &gen.PostRequestEvent{PurchaseId: "id"}),
+		},
+		{
+			name:        "nil event",
+			expectedErr: ErrEmptyEvent,
+		},
+		{
+			name:        "empty payload",
+			event:       NewPostRequest(now, nil),
+			expectedErr: ErrEmptyPayload,
+		},
+		{
+			name: "missing oneof",
+			event: &gen.TelegramNotificationEvent{
+				EventType:     gen.NotificationEventType_POST_REQUEST_EVENT_TYPE,
+				EventDatetime: timestamppb.New(now),
+			},
+			expectedErr: ErrEmptyPayload,
+		},
+		{
+			name: "unspecified type",
+			event: &gen.TelegramNotificationEvent{
+				EventDatetime: timestamppb.New(now),
+				Event:         &gen.TelegramNotificationEvent_PostRequestEvent{PostRequestEvent: &gen.PostRequestEvent{}},
+			},
+			expectedErr: ErrUnspecifiedType,
+		},
+		{
+			name: "empty datetime",
+			event: &gen.TelegramNotificationEvent{
+				EventType: gen.NotificationEventType_POST_REQUEST_EVENT_TYPE,
+				Event:     &gen.TelegramNotificationEvent_PostRequestEvent{PostRequestEvent: &gen.PostRequestEvent{}},
+			},
+			expectedErr: ErrEmptyDatetime,
+		},
+		{
+			name: "post request payload for send adv request type",
+			event: &gen.TelegramNotificationEvent{
+				EventType:     gen.NotificationEventType_SEND_ADV_REQUEST_TO_MANAGER_EVENT_TYPE,
+				EventDatetime: timestamppb.New(now),
+				Event:         &gen.TelegramNotificationEvent_PostRequestEvent{PostRequestEvent: &gen.PostRequestEvent{}},
+			},
+			expectedErr: ErrMismatchedPayload,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.ErrorIs(t, Validate(tc.event), tc.expectedErr)
+		})
+	}
+}
+
+type recordingHandlers struct {
+	called string
+}
+
+func (h *recordingHandlers) BalanceReplenishment(context.Context, *gen.BalanceReplenishmentEvent) error {
+	h.called = "BalanceReplenishment"
+	return nil
+}
+
+func (h *recordingHandlers) BalanceWithdrawal(context.Context, *gen.BalanceWithdrawalEvent) error {
+	h.called = "BalanceWithdrawal"
+	return nil
+}
+
+func (h *recordingHandlers) PostRequest(context.Context, *gen.PostRequestEvent) error {
+	h.called = "PostRequest"
+	return nil
+}
+
+func (h *recordingHandlers) ChangePurchaseStatus(context.Context, *gen.ChangePurchaseStatusEvent) error {
+	h.called = "ChangePurchaseStatus"
+	return nil
+}
+
+func (h *recordingHandlers) SendAdvRequestToManager(context.Context, *gen.PostRequestEvent) error {
+	h.called = "SendAdvRequestToManager"
+	return nil
+}
+
+func (h *recordingHandlers) SendCreativeToUser(context.Context, *gen.SendCreativeToUserEvent) error {
+	h.called = "SendCreativeToUser"
+	return nil
+}
+
+func TestHandle(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		event    *gen.TelegramNotificationEvent
+		expected string
+	}{
+		{NewBalanceReplenishment(now, &gen.BalanceReplenishmentEvent{}), "BalanceReplenishment"},
+		{NewBalanceWithdrawal(now, &gen.BalanceWithdrawalEvent{}), "BalanceWithdrawal"},
+		{NewPostRequest(now, &gen.PostRequestEvent{}), "PostRequest"},
+		{NewChangePurchaseStatus(now, &gen.ChangePurchaseStatusEvent{}), "ChangePurchaseStatus"},
+		{NewSendAdvRequestToManager(now, &gen.PostRequestEvent{}), "SendAdvRequestToManager"},
+		{NewSendCreativeToUser(now, &gen.SendCreativeToUserEvent{}), "SendCreativeToUser"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expected, func(t *testing.T) {
+			h := &recordingHandlers{}
+			assert.NoError(t, Handle(context.Background(), tc.event, h))
+			assert.Equal(t, tc.expected, h.called)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package notification
+
+import (
+	"fmt"
+
+	"github.com/Justksenia/common/schema/kafka/gen"
+	"github.com/go-faster/errors"
+)
+
+var (
+	ErrInvalidEvent      = errors.New("invalid notification event")
+	ErrEmptyEvent        = errors.Wrap(ErrInvalidEvent, "empty event")
+	ErrUnspecifiedType   = errors.Wrap(ErrInvalidEvent, "unspecified event type")
+	ErrEmptyDatetime     = errors.Wrap(ErrInvalidEvent, "empty event datetime")
+	ErrEmptyPayload      = errors.Wrap(ErrInvalidEvent, "empty payload")
+	ErrMismatchedPayload = errors.Wrap(ErrInvalidEvent, "payload doesn't match event type")
+	ErrUnknownPayload    = errors.Wrap(ErrInvalidEvent, "unknown payload")
+)
+
+// Validate checks that event type, datetime and payload of the event are set and consistent.
+func Validate(event *gen.TelegramNotificationEvent) error {
+	if event == nil {
+		return ErrEmptyEvent
+	}
+	if event.GetEventType() == gen.NotificationEventType_UNSPECIFIED_EVENT_TYPE {
+		return ErrUnspecifiedType
+	}
+	if event.GetEventDatetime() == nil {
+		return ErrEmptyDatetime
+	}
+	if event.GetEvent() == nil {
+		return ErrEmptyPayload
+	}
+
+	payloadType, empty, err := payloadOf(event)
+	if err != nil {
+		return err
+	}
+	if payloadType != event.GetEventType() {
+		return errors.Wrap(
+			ErrMismatchedPayload,
+			fmt.Sprintf("event type %s, payload of %s", event.GetEventType(), payloadType),
+		)
+	}
+	if empty {
+		return ErrEmptyPayload
+	}
+	return nil
+}
+
+// payloadOf returns event type which corresponds to the payload and whether the payload message is nil.
+func payloadOf(event *gen.TelegramNotificationEvent) (gen.NotificationEventType, bool, error) {
+	switch payload := event.GetEvent().(type) {
+	case *gen.TelegramNotificationEvent_BalanceReplenishmentEvent:
+		return gen.NotificationEventType_BALANCE_REPLENISHMENT_EVENT_TYPE, payload.BalanceReplenishmentEvent == nil, nil
+	case *gen.TelegramNotificationEvent_BalanceWithdrawalEvent:
+		return gen.NotificationEventType_BALANCE_WITHDRAWAL_EVENT_TYPE, payload.BalanceWithdrawalEvent == nil, nil
+	case *gen.TelegramNotificationEvent_PostRequestEvent:
+		return gen.NotificationEventType_POST_REQUEST_EVENT_TYPE, payload.PostRequestEvent == nil, nil
+	case *gen.TelegramNotificationEvent_ChangePurchaseStatusEvent:
+		return gen.NotificationEventType_CHANGE_PURCHASE_STATUS_EVENT_TYPE, payload.ChangePurchaseStatusEvent == nil, nil
+	case *gen.TelegramNotificationEvent_SendAdvRequestToManagerEvent:
+		return gen.NotificationEventType_SEND_ADV_REQUEST_TO_MANAGER_EVENT_TYPE, payload.SendAdvRequestToManagerEvent == nil, nil
+	case *gen.TelegramNotificationEvent_SendCreativeToUserEvent:
+		return gen.NotificationEventType_SEND_CREATIVE_TO_USER_EVENT_TYPE, payload.SendCreativeToUserEvent == nil, nil
+	default:
+		return gen.NotificationEventType_UNSPECIFIED_EVENT_TYPE, false, errors.Wrap(ErrUnknownPayload, fmt.Sprintf("%T", payload))
+	}
+}
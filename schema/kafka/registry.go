@@ -0,0 +1,198 @@
+package kafka
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Justksenia/common/schema/kafka/gen"
+	"github.com/go-faster/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	ErrInvalidMessage  = errors.New("invalid message")
+	ErrUnknownTopic    = errors.New("unknown topic")
+	ErrTopicNotFound   = errors.New("topic not found")
+	ErrTopicMismatch   = errors.New("topic config mismatch")
+	ErrDuplicatedTopic = errors.New("topic already registered")
+)
+
+// TopicConfig describes how a topic is expected to be laid out on the broker.
+type TopicConfig struct {
+	Partitions int32
+	Retention  time.Duration
+}
+
+// Descriptor is a type-erased view of a Topic, used to enumerate the registry.
+type Descriptor interface {
+	Name() string
+	Config() TopicConfig
+	NewMessage() proto.Message
+}
+
+// Topic binds a topic name to its message type, partition key and config.
+type Topic[T proto.Message] struct {
+	name       string
+	config     TopicConfig
+	newMessage func() T
+	key        func(T) []byte
+	validate   func(T) error
+}
+
+func NewTopic[T proto.Message](
+	name string,
+	config TopicConfig,
+	newMessage func() T,
+	key func(T) []byte,
+	validate func(T) error,
+) *Topic[T] {
+	return &Topic[T]{
+		name:       name,
+		config:     config,
+		newMessage: newMessage,
+		key:        key,
+		validate:   validate,
+	}
+}
+
+func (t *Topic[T]) Name() string {
+	return t.name
+}
+
+func (t *Topic[T]) Config() TopicConfig {
+	return t.config
+}
+
+func (t *Topic[T]) NewMessage() proto.Message {
+	return t.newMessage()
+}
+
+func (t *Topic[T]) Key(msg T) []byte {
+	if t.key == nil {
+		return nil
+	}
+	return t.key(msg)
+}
+
+func (t *Topic[T]) Validate(msg T) error {
+	if t.validate == nil {
+		return nil
+	}
+	if err := t.validate(msg); err != nil {
+		// both the sentinel and the cause stay matchable
+		return fmt.Errorf("%w: %w", ErrInvalidMessage, err)
+	}
+	return nil
+}
+
+// Marshal validates the message and returns the partition key and the encoded value.
+func (t *Topic[T]) Marshal(msg T) (key, value []byte, err error) {
+	if err = t.Validate(msg); err != nil {
+		return nil, nil, err
+	}
+
+	value, err = proto.Marshal(msg)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "marshal message")
+	}
+	return t.Key(msg), value, nil
+}
+
+func (t *Topic[T]) Unmarshal(value []byte) (T, error) {
+	msg := t.newMessage()
+	if err := proto.Unmarshal(value, msg); err != nil {
+		return msg, errors.Wrap(err, "unmarshal message")
+	}
+	return msg, t.Validate(msg)
+}
+
+// Registry is a set of topics indexed by name.
+type Registry struct {
+	topics map[string]Descriptor
+	order  []string
+}
+
+func NewRegistry(topics ...Descriptor) (*Registry, error) {
+	r := &Registry{topics: make(map[string]Descriptor, len(topics))}
+	for _, topic := range topics {
+		if err := r.Register(topic); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *Registry) Register(topic Descriptor) error {
+	if _, ok := r.topics[topic.Name()]; ok {
+		return errors.Wrap(ErrDuplicatedTopic, topic.Name())
+	}
+	r.topics[topic.Name()] = topic
+	r.order = append(r.order, topic.Name())
+	return nil
+}
+
+func (r *Registry) Lookup(name string) (Descriptor, error) {
+	topic, ok := r.topics[name]
+	if !ok {
+		return nil, errors.Wrap(ErrUnknownTopic, name)
+	}
+	return topic, nil
+}
+
+func (r *Registry) Topics() []Descriptor {
+	topics := make([]Descriptor, 0, len(r.order))
+	for _, name := range r.order {
+		topics = append(topics, r.topics[name])
+	}
+	return topics
+}
+
+//nolint:gomnd,gochecknoglobals // default topics
+var (
+	ChannelInviteLinksTopic = NewTopic(
+		ChannelInviteLinksTopicName,
+		TopicConfig{Partitions: 3, Retention: 7 * 24 * time.Hour},
+		func() *gen.ChannelInviteLink { return &gen.ChannelInviteLink{} },
+		func(msg *gen.ChannelInviteLink) []byte { return channelKey(msg.GetChannelId()) },
+		validateChannelInviteLink,
+	)
+
+	EventMessageViewsCountTopic = NewTopic(
+		SchedularEventMessageViewsCount,
+		TopicConfig{Partitions: 3, Retention: 24 * time.Hour},
+		func() *gen.EventMessageViewsCount { return &gen.EventMessageViewsCount{} },
+		func(msg *gen.EventMessageViewsCount) []byte { return channelKey(msg.GetChannelId()) },
+		validateEventMessageViewsCount,
+	)
+)
+
+// DefaultRegistry returns registry with all topics known to this module.
+func DefaultRegistry() *Registry {
+	r, _ := NewRegistry(ChannelInviteLinksTopic, EventMessageViewsCountTopic)
+	return r
+}
+
+func channelKey(channelID int64) []byte {
+	return []byte(strconv.FormatInt(channelID, 10))
+}
+
+func validateChannelInviteLink(msg *gen.ChannelInviteLink) error {
+	if msg.GetChannelId() == 0 {
+		return errors.New("empty channel id")
+	}
+	if msg.GetLink() == "" {
+		return errors.New("empty link")
+	}
+	return nil
+}
+
+func validateEventMessageViewsCount(msg *gen.EventMessageViewsCount) error {
+	if msg.GetChannelId() == 0 {
+		return errors.New("empty channel id")
+	}
+	if len(msg.GetMessageIds()) == 0 {
+		return errors.New("empty message ids")
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Justksenia/common/schema/kafka/gen"
+	"github.com/go-faster/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopic_Marshal(t *testing.T) {
+	testCases := []struct {
+		name        string
+		msg         *gen.ChannelInviteLink
+		expectedKey []byte
+		expectedErr error
+	}{
+		{
+			name:        "valid message",
+			msg:         &gen.ChannelInviteLink{ChannelId: 42, Link: "https://t.me/+5V23yMex8GY5ZWFi"},
+			expectedKey: []byte("42"),
+		},
+		{
+			name:        "empty channel id",
+			msg:         &gen.ChannelInviteLink{Link: "https://t.me/+5V23yMex8GY5ZWFi"},
+			expectedErr: ErrInvalidMessage,
+		},
+		{
+			name:        "empty link",
+			msg:         &gen.ChannelInviteLink{ChannelId: 42},
+			expectedErr: ErrInvalidMessage,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			key, value, err := ChannelInviteLinksTopic.Marshal(tc.msg)
+			assert.ErrorIs(t, err, tc.expectedErr)
+			if tc.expectedErr != nil {
+				return
+			}
+			assert.Equal(t, tc.expectedKey, key)
+
+			decoded, err := ChannelInviteLinksTopic.Unmarshal(value)
+			require.NoError(t, err)
+			assert.Equal(t, tc.msg.GetChannelId(), decoded.GetChannelId())
+			assert.Equal(t, tc.msg.GetLink(), decoded.GetLink())
+		})
+	}
+}
+
+func TestTopic_ValidateKeepsCause(t *testing.T) {
+	errCause := errors.New("cause")
+	topic := NewTopic(
+		"test",
+		TopicConfig{},
+		func() *gen.ChannelInviteLink { return &gen.ChannelInviteLink{} },
+		func(*gen.ChannelInviteLink) []byte { return nil },
+		func(*gen.ChannelInviteLink) error { return errCause },
+	)
+
+	err := topic.Validate(&gen.ChannelInviteLink{})
+	assert.ErrorIs(t, err, ErrInvalidMessage)
+	assert.ErrorIs(t, err, errCause)
+	assert.EqualError(t, err, "invalid message: cause")
+}
+
+func TestRegistry(t *testing.T) {
+	r := DefaultRegistry()
+
+	topic, err := r.Lookup(SchedularEventMessageViewsCount)
+	require.NoError(t, err)
+	assert.IsType(t, &gen.EventMessageViewsCount{}, topic.NewMessage())
+
+	_, err = r.Lookup("unknown")
+	assert.ErrorIs(t, err, ErrUnknownTopic)
+
+	assert.ErrorIs(t, r.Register(ChannelInviteLinksTopic), ErrDuplicatedTopic)
+}
+
+type fakeAdmin struct {
+	topics map[string]TopicConfig
+}
+
+func (a *fakeAdmin) DescribeTopic(_ context.Context, name string) (TopicConfig, error) {
+	cfg, ok := a.topics[name]
+	if !ok {
+		return TopicConfig{}, ErrTopicNotFound
+	}
+	return cfg, nil
+}
+
+func (a *fakeAdmin) CreateTopic(_ context.Context, name string, config TopicConfig) error {
+	a.topics[name] = config
+	return nil
+}
+
+func TestEnsureTopics(t *testing.T) {
+	testCases := []struct {
+		name        string
+		existing    map[string]TopicConfig
+		expectedErr error
+	}{
+		{
+			name:     "create missing topics",
+			existing: map[string]TopicConfig{},
+		},
+		{
+			name: "existing topics match",
+			existing: map[string]TopicConfig{
+				ChannelInviteLinksTopicName:     ChannelInviteLinksTopic.Config(),
+				SchedularEventMessageViewsCount: EventMessageViewsCountTopic.Config(),
+			},
+		},
+		{
+			name: "partitions mismatch",
+			existing: map[string]TopicConfig{
+				ChannelInviteLinksTopicName: {Partitions: 1, Retention: ChannelInviteLinksTopic.Config().Retention},
+			},
+			expectedErr: ErrTopicMismatch,
+		},
+		{
+			name: "retention mismatch",
+			existing: map[string]TopicConfig{
+				ChannelInviteLinksTopicName: {Partitions: ChannelInviteLinksTopic.Config().Partitions, Retention: time.Hour},
+			},
+			expectedErr: ErrTopicMismatch,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			admin := &fakeAdmin{topics: tc.existing}
+			err := EnsureTopics(context.Background(), admin, DefaultRegistry())
+			assert.ErrorIs(t, err, tc.expectedErr)
+			if tc.expectedErr == nil {
+				assert.Len(t, admin.topics, 2)
+			}
+		})
+	}
+}
@@ -0,0 +1,312 @@
+//nolint:gomnd,gochecknoglobals // default values
+package circuitbreaker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-faster/errors"
+)
+
+// ErrOpen is returned without calling the protected operation while the breaker is open
+// or while half-open probes are in flight.
+var ErrOpen = errors.New("circuit breaker is open")
+
+type State int
+
+const (
+	// StateClosed lets all requests through and counts failures.
+	StateClosed State = iota
+	// StateHalfOpen lets a few probe requests through after the cool-down.
+	StateHalfOpen
+	// StateOpen rejects all requests until the cool-down passes.
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// Outcome is the result of a request reported to the breaker.
+type Outcome int
+
+const (
+	// OutcomeSuccess resets consecutive failures and closes the half-open breaker.
+	OutcomeSuccess Outcome = iota
+	// OutcomeFailure counts towards opening the breaker.
+	OutcomeFailure
+	// OutcomeIgnored only releases the request. It's reported for requests canceled by the caller,
+	// they say nothing about health of the service.
+	OutcomeIgnored
+)
+
+type Breaker struct {
+	name string
+
+	// consecutiveFailures opens the breaker after so many failures in a row, 0 disables the threshold.
+	consecutiveFailures int
+	// failureRate opens the breaker when the share of failures in the window reaches it,
+	// if there were at least minRequests. 0 disables the threshold.
+	failureRate      float64
+	minRequests      int
+	window           time.Duration
+	coolDown         time.Duration
+	halfOpenRequests int
+	isFailure        func(error) bool
+	onStateChange    []func(name string, from, to State)
+	now              func() time.Time
+
+	mu          sync.Mutex
+	state       State
+	generation  uint64
+	expires     time.Time
+	requests    int
+	failures    int
+	successes   int
+	consecutive int
+	inFlight    int
+}
+
+type Opts func(b *Breaker)
+
+// WithConsecutiveFailures opens the breaker after n failures in a row.
+func WithConsecutiveFailures(n int) Opts {
+	return func(b *Breaker) {
+		b.consecutiveFailures = n
+	}
+}
+
+// WithFailureRate opens the breaker when the share of failed requests in the window reaches rate.
+// Windows with less than minRequests requests are ignored.
+func WithFailureRate(rate float64, minRequests int, window time.Duration) Opts {
+	return func(b *Breaker) {
+		b.failureRate = rate
+		b.minRequests = minRequests
+		b.window = window
+	}
+}
+
+// WithCoolDown sets how long the breaker stays open before probing.
+func WithCoolDown(d time.Duration) Opts {
+	return func(b *Breaker) {
+		b.coolDown = d
+	}
+}
+
+// WithHalfOpenRequests sets how many probes must succeed in the half-open state to close the breaker.
+func WithHalfOpenRequests(n int) Opts {
+	return func(b *Breaker) {
+		b.halfOpenRequests = n
+	}
+}
+
+// WithIsFailure sets classifier of errors counted as failures, other errors are successes.
+// By default all errors are failures. Canceled requests are ignored whatever the classifier says.
+func WithIsFailure(isFailure func(error) bool) Opts {
+	return func(b *Breaker) {
+		b.isFailure = isFailure
+	}
+}
+
+// WithOnStateChange adds a callback called on every state change. It's called under the lock of the breaker,
+// so it must not call it.
+func WithOnStateChange(hook func(name string, from, to State)) Opts {
+	return func(b *Breaker) {
+		b.onStateChange = append(b.onStateChange, hook)
+	}
+}
+
+func New(name string, opts ...Opts) *Breaker {
+	b := &Breaker{
+		name:                name,
+		consecutiveFailures: 5,
+		window:              time.Minute,
+		coolDown:            30 * time.Second,
+		halfOpenRequests:    1,
+		isFailure:           defaultIsFailure,
+		now:                 time.Now,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.halfOpenRequests < 1 {
+		b.halfOpenRequests = 1
+	}
+
+	b.expires = b.now().Add(b.window)
+	state.WithLabelValues(name).Set(float64(StateClosed))
+	return b
+}
+
+func defaultIsFailure(err error) bool {
+	return err != nil
+}
+
+// outcome classifies the result of a request, canceled requests are always ignored.
+func outcome(err error, isFailure func(error) bool) Outcome {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return OutcomeIgnored
+	case isFailure(err):
+		return OutcomeFailure
+	default:
+		return OutcomeSuccess
+	}
+}
+
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh(b.now())
+	return b.state
+}
+
+// Execute calls f if the breaker allows it and records the result. ErrOpen is returned otherwise.
+func (b *Breaker) Execute(ctx context.Context, f func(ctx context.Context) error) error {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			done(OutcomeFailure)
+			panic(r)
+		}
+	}()
+	err = f(ctx)
+	done(outcome(err, b.isFailure))
+	return err
+}
+
+// Allow reserves a request. The caller must report the outcome of the request by calling done exactly once.
+// It's meant for adapters which classify results themselves, Execute should be preferred otherwise.
+func (b *Breaker) Allow() (done func(outcome Outcome), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.refresh(now)
+	switch {
+	case b.state == StateOpen:
+		return nil, errors.Wrap(ErrOpen, b.name)
+	case b.state == StateHalfOpen && b.inFlight >= b.halfOpenRequests:
+		return nil, errors.Wrap(ErrOpen, b.name)
+	}
+
+	b.inFlight++
+	generation := b.generation
+	var once sync.Once
+	return func(outcome Outcome) {
+		once.Do(func() {
+			b.done(generation, outcome)
+		})
+	}, nil
+}
+
+func (b *Breaker) done(generation uint64, outcome Outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.refresh(now)
+	// results of requests started before the last state change don't describe the current state
+	if generation != b.generation {
+		return
+	}
+	b.inFlight--
+	if outcome == OutcomeIgnored {
+		return
+	}
+
+	b.requests++
+	if outcome == OutcomeFailure {
+		b.failures++
+		b.consecutive++
+		if b.state == StateHalfOpen || b.tripped() {
+			b.setState(StateOpen, now)
+		}
+		return
+	}
+
+	b.successes++
+	b.consecutive = 0
+	if b.state == StateHalfOpen && b.successes >= b.halfOpenRequests {
+		b.setState(StateClosed, now)
+	}
+}
+
+func (b *Breaker) tripped() bool {
+	if b.consecutiveFailures > 0 && b.consecutive >= b.consecutiveFailures {
+		return true
+	}
+	return b.failureRate > 0 && b.requests >= b.minRequests &&
+		float64(b.failures)/float64(b.requests) >= b.failureRate
+}
+
+// refresh moves the open breaker to half-open after the cool-down and starts a new window of the closed one.
+func (b *Breaker) refresh(now time.Time) {
+	switch b.state {
+	case StateOpen:
+		if !now.Before(b.expires) {
+			b.setState(StateHalfOpen, now)
+		}
+	case StateClosed:
+		if b.window > 0 && !now.Before(b.expires) {
+			b.reset(now.Add(b.window))
+		}
+	case StateHalfOpen:
+	}
+}
+
+func (b *Breaker) setState(to State, now time.Time) {
+	from := b.state
+	b.state = to
+	b.generation++
+
+	b.consecutive = 0
+	switch to {
+	case StateOpen:
+		b.reset(now.Add(b.coolDown))
+	case StateClosed:
+		b.reset(now.Add(b.window))
+	case StateHalfOpen:
+		b.reset(time.Time{})
+	}
+	b.inFlight = 0
+
+	state.WithLabelValues(b.name).Set(float64(to))
+	transitions.WithLabelValues(b.name, to.String()).Inc()
+	for _, hook := range b.onStateChange {
+		hook(b.name, from, to)
+	}
+}
+
+// reset starts a new window. Consecutive failures aren't reset, they may span windows.
+func (b *Breaker) reset(expires time.Time) {
+	b.expires = expires
+	b.requests = 0
+	b.failures = 0
+	b.successes = 0
+}
+
+// Retryable reports whether the error may be retried, it's meant for retrier.WithRetryIf.
+// Retrying while the breaker is open would only be rejected again.
+func Retryable(err error) bool {
+	return !errors.Is(err, ErrOpen)
+}
@@ -0,0 +1,217 @@
+package circuitbreaker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Justksenia/common/utils/retrier"
+	"github.com/go-faster/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errTest = errors.New("test")
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newTestBreaker(t *testing.T, opts ...Opts) (*Breaker, *clock, *[]string) {
+	t.Helper()
+
+	c := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	var changes []string
+	opts = append(opts, WithOnStateChange(func(_ string, from, to State) {
+		changes = append(changes, from.String()+"->"+to.String())
+	}))
+	b := New(t.Name(), opts...)
+	b.now = c.Now
+	b.expires = c.now.Add(b.window)
+	return b, c, &changes
+}
+
+func call(b *Breaker, err error) error {
+	return b.Execute(context.Background(), func(context.Context) error {
+		return err
+	})
+}
+
+func TestBreaker_ConsecutiveFailures(t *testing.T) {
+	b, c, changes := newTestBreaker(t, WithConsecutiveFailures(3), WithCoolDown(time.Minute), WithHalfOpenRequests(2))
+
+	require.ErrorIs(t, call(b, errTest), errTest)
+	require.ErrorIs(t, call(b, errTest), errTest)
+	require.NoError(t, call(b, nil))
+	assert.Equal(t, StateClosed, b.State())
+
+	for i := 0; i < 3; i++ {
+		require.ErrorIs(t, call(b, errTest), errTest)
+	}
+	assert.Equal(t, StateOpen, b.State())
+	assert.ErrorIs(t, call(b, nil), ErrOpen)
+	assert.InDelta(t, float64(StateOpen), testutil.ToFloat64(state.WithLabelValues(t.Name())), 0)
+
+	c.now = c.now.Add(time.Minute)
+	assert.Equal(t, StateHalfOpen, b.State())
+
+	// only the configured number of probes is let through
+	done1, err := b.Allow()
+	require.NoError(t, err)
+	done2, err := b.Allow()
+	require.NoError(t, err)
+	_, err = b.Allow()
+	require.ErrorIs(t, err, ErrOpen)
+
+	done1(OutcomeSuccess)
+	assert.Equal(t, StateHalfOpen, b.State())
+	done2(OutcomeSuccess)
+	assert.Equal(t, StateClosed, b.State())
+
+	assert.Equal(t, []string{"closed->open", "open->half-open", "half-open->closed"}, *changes)
+}
+
+func TestBreaker_HalfOpenFailure(t *testing.T) {
+	b, c, changes := newTestBreaker(t, WithConsecutiveFailures(1), WithCoolDown(time.Second))
+
+	require.ErrorIs(t, call(b, errTest), errTest)
+	c.now = c.now.Add(time.Second)
+	require.ErrorIs(t, call(b, errTest), errTest)
+	assert.Equal(t, StateOpen, b.State())
+	assert.Equal(t, []string{"closed->open", "open->half-open", "half-open->open"}, *changes)
+}
+
+func TestBreaker_FailureRate(t *testing.T) {
+	b, c, _ := newTestBreaker(t, WithConsecutiveFailures(0), WithFailureRate(0.5, 4, time.Minute))
+
+	require.ErrorIs(t, call(b, errTest), errTest)
+	require.NoError(t, call(b, nil))
+	require.ErrorIs(t, call(b, errTest), errTest)
+	// not enough requests yet
+	assert.Equal(t, StateClosed, b.State())
+
+	// failures of the previous window are forgotten
+	c.now = c.now.Add(time.Minute)
+	require.NoError(t, call(b, nil))
+	require.NoError(t, call(b, nil))
+	require.ErrorIs(t, call(b, errTest), errTest)
+	assert.Equal(t, StateClosed, b.State())
+	require.ErrorIs(t, call(b, errTest), errTest)
+	assert.Equal(t, StateOpen, b.State())
+}
+
+func TestBreaker_IgnoredErrors(t *testing.T) {
+	b, _, _ := newTestBreaker(t, WithConsecutiveFailures(1), WithIsFailure(func(err error) bool {
+		return err != nil && !errors.Is(err, errTest)
+	}))
+
+	require.ErrorIs(t, call(b, errTest), errTest)
+	require.ErrorIs(t, call(b, errTest), errTest)
+	assert.Equal(t, StateClosed, b.State())
+
+	// canceled requests don't count by default
+	b, _, _ = newTestBreaker(t, WithConsecutiveFailures(1))
+	require.ErrorIs(t, call(b, context.Canceled), context.Canceled)
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_CanceledProbe(t *testing.T) {
+	b, c, changes := newTestBreaker(t, WithConsecutiveFailures(1), WithCoolDown(time.Second))
+
+	require.ErrorIs(t, call(b, errTest), errTest)
+	c.now = c.now.Add(time.Second)
+
+	// the canceled probe neither closes the breaker nor holds its slot
+	require.ErrorIs(t, call(b, context.Canceled), context.Canceled)
+	assert.Equal(t, StateHalfOpen, b.State())
+	require.NoError(t, call(b, nil))
+	assert.Equal(t, StateClosed, b.State())
+	assert.Equal(t, []string{"closed->open", "open->half-open", "half-open->closed"}, *changes)
+}
+
+func TestBreaker_ConsecutiveFailuresSpanWindows(t *testing.T) {
+	b, c, _ := newTestBreaker(t, WithConsecutiveFailures(3))
+
+	require.ErrorIs(t, call(b, errTest), errTest)
+	require.ErrorIs(t, call(b, errTest), errTest)
+	// neither cancellations nor a new window reset consecutive failures
+	require.ErrorIs(t, call(b, context.Canceled), context.Canceled)
+	c.now = c.now.Add(time.Minute)
+	assert.Equal(t, StateClosed, b.State())
+
+	require.ErrorIs(t, call(b, errTest), errTest)
+	assert.Equal(t, StateOpen, b.State())
+}
+
+func TestBreaker_Retrier(t *testing.T) {
+	b, _, _ := newTestBreaker(t, WithConsecutiveFailures(2))
+	r := retrier.NewRetrier(
+		retrier.WithRetryPolicy(retrier.RetryPolicy{MaxAttempts: 5, BackoffCoefficient: 1}),
+		retrier.WithRetryIf(Retryable),
+	)
+
+	var calls int
+	err := r.Wrap(context.Background(), "test", func() error {
+		return b.Execute(context.Background(), func(context.Context) error {
+			calls++
+			return errTest
+		})
+	})
+	assert.ErrorIs(t, err, ErrOpen)
+	assert.Equal(t, 2, calls)
+}
+
+func TestRoundTripper(t *testing.T) {
+	status := http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	b, _, _ := newTestBreaker(t, WithConsecutiveFailures(2))
+	client := &http.Client{Transport: RoundTripper(b, nil)}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	}
+
+	status = http.StatusOK
+	_, err := client.Get(server.URL) //nolint:bodyclose // no response
+	assert.ErrorIs(t, err, ErrOpen)
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	b, _, _ := newTestBreaker(t, WithConsecutiveFailures(1))
+	interceptor := UnaryClientInterceptor(b)
+
+	invoke := func(err error) grpc.UnaryInvoker {
+		return func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+			return err
+		}
+	}
+
+	notFound := status.Error(codes.NotFound, "missing")
+	require.Equal(t, notFound, interceptor(context.Background(), "/test", nil, nil, nil, invoke(notFound)))
+	assert.Equal(t, StateClosed, b.State())
+
+	unavailable := status.Error(codes.Unavailable, "down")
+	require.Equal(t, unavailable, interceptor(context.Background(), "/test", nil, nil, nil, invoke(unavailable)))
+	assert.Equal(t, StateOpen, b.State())
+
+	err := interceptor(context.Background(), "/test", nil, nil, nil, invoke(nil))
+	assert.ErrorIs(t, err, ErrOpen)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
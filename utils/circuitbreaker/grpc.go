@@ -0,0 +1,73 @@
+package circuitbreaker
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCFailure reports whether the gRPC error means the server is unhealthy. Errors caused by the request
+// itself, like InvalidArgument or NotFound, don't count.
+func GRPCFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown:
+		return true
+	default:
+		return false
+	}
+}
+
+func grpcOutcome(err error) Outcome {
+	if status.Code(err) == codes.Canceled {
+		return OutcomeIgnored
+	}
+	return outcome(err, GRPCFailure)
+}
+
+// UnaryClientInterceptor protects unary calls with the breaker, failures are classified by GRPCFailure.
+func UnaryClientInterceptor(b *Breaker) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		done, err := b.Allow()
+		if err != nil {
+			return &openError{err: err}
+		}
+
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		done(grpcOutcome(err))
+		return err
+	}
+}
+
+// StreamClientInterceptor protects opening of streams with the breaker. Errors of messages
+// of the established stream aren't counted.
+func StreamClientInterceptor(b *Breaker) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		done, err := b.Allow()
+		if err != nil {
+			return nil, &openError{err: err}
+		}
+
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		done(grpcOutcome(err))
+		return stream, err
+	}
+}
+
+// openError is ErrOpen which is seen by gRPC as UNAVAILABLE status.
+type openError struct {
+	err error
+}
+
+func (e *openError) Error() string {
+	return e.err.Error()
+}
+
+func (e *openError) Unwrap() error {
+	return e.err
+}
+
+func (e *openError) GRPCStatus() *status.Status {
+	return status.New(codes.Unavailable, e.err.Error())
+}
@@ -0,0 +1,36 @@
+package circuitbreaker
+
+import "net/http"
+
+type roundTripper struct {
+	breaker *Breaker
+	next    http.RoundTripper
+}
+
+// RoundTripper protects the transport with the breaker. Transport errors and 5xx responses are failures,
+// http.DefaultTransport is used when next is nil.
+func RoundTripper(b *Breaker, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &roundTripper{breaker: b, next: next}
+}
+
+func (t *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	done, err := t.breaker.Allow()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		done(outcome(err, t.breaker.isFailure))
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		done(OutcomeFailure)
+	} else {
+		done(OutcomeSuccess)
+	}
+	return resp, nil
+}
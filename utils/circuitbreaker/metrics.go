@@ -0,0 +1,28 @@
+package circuitbreaker
+
+import "github.com/prometheus/client_golang/prometheus"
+
+//nolint:gochecknoglobals // metrics are shared by all breakers
+var (
+	state = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "circuit_breaker_state",
+			Help: "State of the circuit breaker: 0 is closed, 1 is half-open, 2 is open",
+		},
+		[]string{"name"},
+	)
+
+	transitions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "circuit_breaker_transitions_total",
+			Help: "Total number of state changes of the circuit breaker by the new state",
+		},
+		[]string{"name", "state"},
+	)
+)
+
+// RegisterMetrics registers metrics of all breakers in the default registry. It must be called once.
+func RegisterMetrics() {
+	prometheus.MustRegister(state)
+	prometheus.MustRegister(transitions)
+}
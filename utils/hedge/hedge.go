@@ -0,0 +1,207 @@
+//nolint:gomnd,gochecknoglobals // default values
+package hedge
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// Hedger sends a second attempt of a slow call when the first one takes longer than the latency percentile
+// of recent calls, and takes the first success. Only idempotent operations may be hedged, e.g. KeyDB reads
+// or GET requests.
+type Hedger struct {
+	name         string
+	percentile   float64
+	minDelay     time.Duration
+	initialDelay time.Duration
+	minSamples   int
+
+	mu        sync.Mutex
+	samples   []time.Duration
+	next      int
+	recorded  int
+	threshold time.Duration
+}
+
+type Opts func(h *Hedger)
+
+// WithPercentile sets the latency percentile after which the hedge is sent, 0.95 by default.
+// Values outside of (0, 1] are ignored.
+func WithPercentile(p float64) Opts {
+	return func(h *Hedger) {
+		h.percentile = p
+	}
+}
+
+// WithMinDelay sets the minimal delay before the hedge, so fast calls are never doubled.
+func WithMinDelay(d time.Duration) Opts {
+	return func(h *Hedger) {
+		h.minDelay = d
+	}
+}
+
+// WithInitialDelay sets the delay before the hedge used until enough latencies are recorded.
+func WithInitialDelay(d time.Duration) Opts {
+	return func(h *Hedger) {
+		h.initialDelay = d
+	}
+}
+
+// WithWindow sets how many recent latencies the percentile is computed from, 1000 by default.
+// Sizes below 1 are ignored.
+func WithWindow(size int) Opts {
+	return func(h *Hedger) {
+		if size >= 1 {
+			h.samples = make([]time.Duration, size)
+		}
+	}
+}
+
+const defaultPercentile = 0.95
+
+func New(name string, opts ...Opts) *Hedger {
+	h := &Hedger{
+		name:         name,
+		percentile:   defaultPercentile,
+		initialDelay: 100 * time.Millisecond,
+		samples:      make([]time.Duration, 1000),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.percentile <= 0 || h.percentile > 1 {
+		h.percentile = defaultPercentile
+	}
+	h.minSamples = min(len(h.samples), 20)
+	h.threshold = h.initialDelay
+	return h
+}
+
+// Delay returns how long the first attempt waits before the hedge is sent.
+func (h *Hedger) Delay() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return max(h.threshold, h.minDelay)
+}
+
+func (h *Hedger) record(latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.samples[h.next] = latency
+	h.next = (h.next + 1) % len(h.samples)
+	h.recorded++
+	// sorting the window on every call is too expensive, the percentile doesn't change that fast
+	if h.recorded < h.minSamples || h.recorded%h.minSamples != 0 {
+		return
+	}
+
+	sorted := slices.Clone(h.samples[:min(h.recorded, len(h.samples))])
+	slices.Sort(sorted)
+	i := int(math.Ceil(h.percentile*float64(len(sorted)))) - 1
+	h.threshold = sorted[max(0, min(i, len(sorted)-1))]
+}
+
+type result[T any] struct {
+	value  T
+	err    error
+	hedge  bool
+	cancel context.CancelFunc
+}
+
+// Do calls f and, if it doesn't return within Delay, calls it once more concurrently. The first success
+// is returned and the other attempt is canceled. Errors aren't hedged, e.g. a cache miss would only double
+// the load, retrier should be used for them. If both attempts fail, their errors are combined with multierr.
+func Do[T any](ctx context.Context, h *Hedger, f func(ctx context.Context) (T, error)) (T, error) {
+	return do(ctx, h, f, nil, func(_ T, cancel context.CancelFunc) { cancel() })
+}
+
+// do runs the attempts. discard releases the result of the attempt which lost the race and keep
+// takes ownership of the context of the winner, e.g. to cancel it when the response body is closed.
+func do[T any](
+	ctx context.Context,
+	h *Hedger,
+	f func(ctx context.Context) (T, error),
+	discard func(T),
+	keep func(T, context.CancelFunc),
+) (T, error) {
+	results := make(chan result[T], 2)
+	var cancels []context.CancelFunc
+	start := func(hedge bool) {
+		attemptCtx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+		started := time.Now()
+		go func() {
+			value, err := f(attemptCtx)
+			if err == nil {
+				h.record(time.Since(started))
+			}
+			results <- result[T]{value: value, err: err, hedge: hedge, cancel: cancel}
+		}()
+	}
+
+	start(false)
+	timer := time.NewTimer(h.Delay())
+	defer timer.Stop()
+
+	var (
+		running = 1
+		hedged  = false
+		errs    error
+		zero    T
+	)
+	sendHedge := func() {
+		hedged = true
+		running++
+		hedges.WithLabelValues(h.name).Inc()
+		start(true)
+	}
+
+	for running > 0 {
+		select {
+		case <-timer.C:
+			if !hedged && ctx.Err() == nil {
+				sendHedge()
+			}
+		case res := <-results:
+			running--
+			if res.err != nil {
+				res.cancel()
+				errs = multierr.Append(errs, res.err)
+				continue
+			}
+
+			if res.hedge {
+				wins.WithLabelValues(h.name).Inc()
+			}
+			keep(res.value, res.cancel)
+			if running > 0 {
+				cancels[len(cancels)-1-boolToInt(res.hedge)]()
+				go drain(results, discard)
+			}
+			return res.value, nil
+		}
+	}
+	return zero, errs
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// drain releases the result of the canceled attempt which lost the race.
+func drain[T any](results <-chan result[T], discard func(T)) {
+	res := <-results
+	if res.err == nil && discard != nil {
+		discard(res.value)
+	}
+}
@@ -0,0 +1,158 @@
+package hedge
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-faster/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTest = errors.New("test")
+
+func TestDo(t *testing.T) {
+	testCases := []struct {
+		name     string
+		first    func(ctx context.Context) (string, error)
+		second   func(ctx context.Context) (string, error)
+		result   string
+		err      error
+		attempts int32
+	}{
+		{
+			name:     "fast call isn't hedged",
+			first:    func(context.Context) (string, error) { return "first", nil },
+			result:   "first",
+			attempts: 1,
+		},
+		{
+			name: "slow call is hedged",
+			first: func(ctx context.Context) (string, error) {
+				<-ctx.Done()
+				return "", ctx.Err()
+			},
+			second:   func(context.Context) (string, error) { return "second", nil },
+			result:   "second",
+			attempts: 2,
+		},
+		{
+			name:     "failed call isn't hedged",
+			first:    func(context.Context) (string, error) { return "", errTest },
+			err:      errTest,
+			attempts: 1,
+		},
+		{
+			name: "both failed",
+			first: func(context.Context) (string, error) {
+				time.Sleep(50 * time.Millisecond)
+				return "", errTest
+			},
+			second:   func(context.Context) (string, error) { return "", context.DeadlineExceeded },
+			err:      context.DeadlineExceeded,
+			attempts: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := New(t.Name(), WithInitialDelay(10*time.Millisecond))
+
+			var attempts atomic.Int32
+			result, err := Do(context.Background(), h, func(ctx context.Context) (string, error) {
+				if attempts.Add(1) == 1 {
+					return tc.first(ctx)
+				}
+				return tc.second(ctx)
+			})
+			assert.Equal(t, tc.attempts, attempts.Load())
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				assert.ErrorIs(t, err, errTest)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.result, result)
+		})
+	}
+}
+
+func TestDo_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var attempts atomic.Int32
+	_, err := Do(ctx, New(t.Name(), WithInitialDelay(time.Millisecond)), func(context.Context) (string, error) {
+		attempts.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		return "", errTest
+	})
+	assert.ErrorIs(t, err, errTest)
+	assert.Equal(t, int32(1), attempts.Load())
+}
+
+func TestHedger_Delay(t *testing.T) {
+	h := New("delay", WithWindow(100), WithPercentile(0.9), WithInitialDelay(time.Second), WithMinDelay(5*time.Millisecond))
+	assert.Equal(t, time.Second, h.Delay())
+
+	for i := 1; i <= 100; i++ {
+		h.record(time.Duration(i) * time.Millisecond)
+	}
+	assert.Equal(t, 90*time.Millisecond, h.Delay())
+
+	for i := 0; i < 100; i++ {
+		h.record(time.Millisecond)
+	}
+	assert.Equal(t, 5*time.Millisecond, h.Delay())
+}
+
+func TestNew_InvalidOptions(t *testing.T) {
+	for _, opts := range [][]Opts{
+		{WithWindow(0)},
+		{WithWindow(-1)},
+		{WithPercentile(0)},
+		{WithPercentile(1.5)},
+	} {
+		h := New("invalid", append(opts, WithInitialDelay(time.Second))...)
+		assert.InDelta(t, defaultPercentile, h.percentile, 0)
+		assert.Len(t, h.samples, 1000)
+
+		for i := 1; i <= 100; i++ {
+			h.record(time.Duration(i) * time.Millisecond)
+		}
+		assert.Equal(t, 95*time.Millisecond, h.Delay())
+	}
+}
+
+func TestRoundTripper(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 && r.Method == http.MethodGet {
+			<-r.Context().Done()
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: RoundTripper(New("http", WithInitialDelay(10*time.Millisecond)), nil)}
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, "ok", string(body))
+	assert.Equal(t, int32(2), requests.Load())
+
+	// requests with body aren't hedged
+	resp, err = client.Post(server.URL, "text/plain", http.NoBody)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, int32(3), requests.Load())
+}
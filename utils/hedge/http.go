@@ -0,0 +1,51 @@
+package hedge
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+type roundTripper struct {
+	hedger *Hedger
+	next   http.RoundTripper
+}
+
+// RoundTripper hedges GET and HEAD requests without body, other requests are passed to next as is.
+// http.DefaultTransport is used when next is nil.
+func RoundTripper(h *Hedger, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &roundTripper{hedger: h, next: next}
+}
+
+func (t *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if (req.Method != http.MethodGet && req.Method != http.MethodHead) || (req.Body != nil && req.Body != http.NoBody) {
+		return t.next.RoundTrip(req)
+	}
+
+	return do(req.Context(), t.hedger,
+		func(ctx context.Context) (*http.Response, error) {
+			return t.next.RoundTrip(req.Clone(ctx))
+		},
+		func(resp *http.Response) {
+			_ = resp.Body.Close()
+		},
+		func(resp *http.Response, cancel context.CancelFunc) {
+			// the body is read with the context of the attempt, so it's canceled once the body is closed
+			resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+		},
+	)
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
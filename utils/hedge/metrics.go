@@ -0,0 +1,28 @@
+package hedge
+
+import "github.com/prometheus/client_golang/prometheus"
+
+//nolint:gochecknoglobals // metrics are shared by all hedgers
+var (
+	hedges = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hedge_requests_total",
+			Help: "Total number of hedged attempts sent by operation",
+		},
+		[]string{"name"},
+	)
+
+	wins = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hedge_wins_total",
+			Help: "Total number of calls for which the hedged attempt returned first",
+		},
+		[]string{"name"},
+	)
+)
+
+// RegisterMetrics registers metrics of all hedgers in the default registry. It must be called once.
+func RegisterMetrics() {
+	prometheus.MustRegister(hedges)
+	prometheus.MustRegister(wins)
+}
@@ -0,0 +1,88 @@
+package retrier
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-faster/errors"
+)
+
+// ErrBudgetExhausted is returned with the last error when the retry budget doesn't allow one more retry.
+var ErrBudgetExhausted = errors.New("retry budget exhausted")
+
+// Budget limits retries to a share of recent calls, so a failing dependency isn't hit by a multiple of
+// the usual load. A single budget is meant to be shared by all retriers of a service or of a dependency.
+type Budget struct {
+	ratio      float64
+	minRetries float64
+	bucketSize time.Duration
+	now        func() time.Time
+
+	mu      sync.Mutex
+	buckets []budgetBucket
+}
+
+type budgetBucket struct {
+	start   time.Time
+	calls   int
+	retries int
+}
+
+const (
+	budgetBuckets   = 10
+	minBudgetWindow = time.Second
+)
+
+// NewBudget creates a budget which allows retries while they stay under ratio of calls made within the window,
+// e.g. 0.1 for 10%. minPerSecond retries per second are allowed regardless of the ratio, so rarely called
+// operations can still be retried. Windows shorter than a second are extended to a second.
+func NewBudget(ratio float64, minPerSecond int, window time.Duration) *Budget {
+	window = max(window, minBudgetWindow)
+	return &Budget{
+		ratio:      ratio,
+		minRetries: float64(minPerSecond) * window.Seconds(),
+		bucketSize: window / budgetBuckets,
+		now:        time.Now,
+		buckets:    make([]budgetBucket, budgetBuckets),
+	}
+}
+
+// RecordCall counts the first attempt of a call.
+func (b *Budget) RecordCall() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.bucket(b.now()).calls++
+}
+
+// TryRetry withdraws a retry from the budget, it returns false when the budget is exhausted.
+func (b *Budget) TryRetry() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	current := b.bucket(now)
+
+	var calls, retries int
+	for _, bucket := range b.buckets {
+		if now.Sub(bucket.start) < b.bucketSize*budgetBuckets {
+			calls += bucket.calls
+			retries += bucket.retries
+		}
+	}
+	if float64(retries+1) > b.ratio*float64(calls)+b.minRetries {
+		return false
+	}
+	current.retries++
+	return true
+}
+
+// bucket returns the bucket of the moment, resetting it when it belongs to the previous window.
+func (b *Budget) bucket(now time.Time) *budgetBucket {
+	start := now.Truncate(b.bucketSize)
+	bucket := &b.buckets[int(start.UnixNano()/int64(b.bucketSize))%budgetBuckets]
+	if !bucket.start.Equal(start) {
+		*bucket = budgetBucket{start: start}
+	}
+	return bucket
+}
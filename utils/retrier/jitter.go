@@ -0,0 +1,41 @@
+package retrier
+
+import "time"
+
+// Jitter randomizes delays between attempts, so clients which failed together don't retry together.
+// See https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/.
+type Jitter int
+
+const (
+	// JitterNone waits exactly the exponential backoff.
+	JitterNone Jitter = iota
+	// JitterFull waits a random delay from zero to the backoff.
+	JitterFull
+	// JitterEqual waits half of the backoff and a random delay up to the other half.
+	JitterEqual
+	// JitterDecorrelated waits a random delay from StartDelay to three times the previous delay,
+	// limited by MaxDelay. It doesn't depend on BackoffCoefficient.
+	JitterDecorrelated
+)
+
+// jitter returns delay before the next attempt for the exponential backoff and the previous delay.
+func (r *Retrier) jitter(backoff, previous time.Duration) time.Duration {
+	switch r.policy.Jitter {
+	case JitterFull:
+		return r.between(0, backoff)
+	case JitterEqual:
+		return backoff/2 + r.between(0, backoff-backoff/2)
+	case JitterDecorrelated:
+		return r.limit(r.between(r.policy.StartDelay, 3*previous))
+	default:
+		return backoff
+	}
+}
+
+// between returns a random duration in [low, high].
+func (r *Retrier) between(low, high time.Duration) time.Duration {
+	if high <= low {
+		return low
+	}
+	return low + time.Duration(r.random(int64(high-low)+1))
+}
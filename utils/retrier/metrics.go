@@ -0,0 +1,37 @@
+package retrier
+
+import "github.com/prometheus/client_golang/prometheus"
+
+//nolint:gochecknoglobals // metrics are shared by all retriers
+var (
+	attempts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "retrier_attempts_total",
+			Help: "Total number of attempts by retried operation",
+		},
+		[]string{"name"},
+	)
+
+	successes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "retrier_successes_total",
+			Help: "Total number of retried operations which succeeded",
+		},
+		[]string{"name"},
+	)
+
+	giveUps = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "retrier_give_ups_total",
+			Help: "Total number of retried operations which failed: attempts were exhausted, the error wasn't retryable or context was done",
+		},
+		[]string{"name"},
+	)
+)
+
+// RegisterMetrics registers metrics of all retriers in the default registry. It must be called once.
+func RegisterMetrics() {
+	prometheus.MustRegister(attempts)
+	prometheus.MustRegister(successes)
+	prometheus.MustRegister(giveUps)
+}
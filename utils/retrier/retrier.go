@@ -3,24 +3,46 @@ package retrier
 
 import (
 	"context"
+	"fmt"
+	"math/rand/v2"
 	"time"
 
+	cmnlogger "github.com/Justksenia/common/logger"
+	"github.com/Justksenia/common/tracer"
 	"github.com/go-faster/errors"
 	"github.com/samber/lo"
-	cmnlogger "github.com/Justksenia/common"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
 )
 
 type RetryPolicy struct {
+	// MaxAttempts includes the first call, f is called once if it isn't positive.
 	MaxAttempts        int
 	StartDelay         time.Duration
 	MaxDelay           *time.Duration
 	BackoffCoefficient float32
+	Jitter             Jitter
+}
+
+// RetryInfo describes a failed attempt which is going to be retried.
+type RetryInfo struct {
+	Name    string
+	Attempt int
+	Err     error
+	Delay   time.Duration
 }
 
 type Retrier struct {
 	policy         RetryPolicy
 	excludedErrors []error
+	retryIf        func(error) bool
+	retryAfter     func(error) (time.Duration, bool)
+	maxRetryAfter  time.Duration
+	onRetry        []func(ctx context.Context, info RetryInfo)
+	budget         *Budget
+	// random returns a number in [0, n), it's replaced in tests.
+	random func(n int64) int64
 }
 
 type Opts func(r *Retrier)
@@ -37,6 +59,43 @@ func WithExcludedErrors(errors ...error) Opts {
 	}
 }
 
+// WithRetryIf sets classifier of retryable errors. Errors for which it returns false are returned immediately.
+// Excluded errors are never retried regardless of the classifier.
+func WithRetryIf(retryIf func(error) bool) Opts {
+	return func(r *Retrier) {
+		r.retryIf = retryIf
+	}
+}
+
+// WithRetryAfter replaces extraction of the delay requested by the server, RetryAfter by default.
+func WithRetryAfter(retryAfter func(error) (time.Duration, bool)) Opts {
+	return func(r *Retrier) {
+		r.retryAfter = retryAfter
+	}
+}
+
+// WithMaxRetryAfter limits the delay requested by the server, 1 minute by default. The retrier gives up
+// instead of waiting longer, the last error is returned combined with ErrRetryAfterTooLong.
+func WithMaxRetryAfter(d time.Duration) Opts {
+	return func(r *Retrier) {
+		r.maxRetryAfter = d
+	}
+}
+
+// WithOnRetry adds a hook called before waiting for the next attempt.
+func WithOnRetry(hook func(ctx context.Context, info RetryInfo)) Opts {
+	return func(r *Retrier) {
+		r.onRetry = append(r.onRetry, hook)
+	}
+}
+
+// WithBudget limits retries by the budget shared with other retriers.
+func WithBudget(budget *Budget) Opts {
+	return func(r *Retrier) {
+		r.budget = budget
+	}
+}
+
 var defaultPolicy = RetryPolicy{
 	MaxAttempts:        3,
 	StartDelay:         1 * time.Second,
@@ -44,39 +103,139 @@ var defaultPolicy = RetryPolicy{
 	BackoffCoefficient: 2,
 }
 
+const defaultMaxRetryAfter = time.Minute
+
 func NewRetrier(opts ...Opts) *Retrier {
-	retrier := &Retrier{policy: defaultPolicy}
+	retrier := &Retrier{
+		policy:        defaultPolicy,
+		retryAfter:    RetryAfter,
+		maxRetryAfter: defaultMaxRetryAfter,
+		random:        rand.Int64N,
+	}
 
 	for _, opt := range opts {
 		opt(retrier)
 	}
+	retrier.policy.MaxAttempts = max(retrier.policy.MaxAttempts, 1)
 	return retrier
 }
 
-func (r *Retrier) Wrap(ctx context.Context, name string, f func() error) (err error) {
+// Wrap calls f until it succeeds, returns a non-retryable error or attempts are exhausted. See Do.
+func (r *Retrier) Wrap(ctx context.Context, name string, f func() error) error {
+	_, err := Do(ctx, r, name, func(context.Context, int) (struct{}, error) {
+		return struct{}{}, f()
+	})
+	return err
+}
+
+// Do calls f until it succeeds, returns a non-retryable error or attempts are exhausted, then *ExhaustedError
+// with errors of all attempts is returned. When the budget runs out, the last error is returned combined
+// with ErrBudgetExhausted. Every attempt runs in its own span. Waiting between attempts
+// is aborted when ctx is done, then the last error is returned combined with ctx.Err().
+func Do[T any](ctx context.Context, r *Retrier, name string, f func(ctx context.Context, attempt int) (T, error)) (T, error) {
 	logger := cmnlogger.FromContext(ctx).With(zap.String("method", "retrier"), zap.String("name", name))
 
-	delay := r.policy.StartDelay
+	var (
+		result T
+		err    error
+		errs   error
+	)
+	if r.budget != nil {
+		r.budget.RecordCall()
+	}
+
+	backoff := r.policy.StartDelay
+	delay := backoff
 	for i := 1; i <= r.policy.MaxAttempts; i++ {
 		logger.Debug("start execution", zap.Int("attempt", i))
 
-		if err = f(); err == nil || r.checkExcludedErrors(err) {
-			break
+		result, err = attempt(ctx, name, i, f)
+		if err == nil {
+			successes.WithLabelValues(name).Inc()
+			logger.Debug("execution finished")
+			return result, nil
+		}
+		errs = multierr.Append(errs, err)
+		if !r.retryable(err) {
+			giveUps.WithLabelValues(name).Inc()
+			return result, err
 		}
 		logger.Warn("error occurred during execution", zap.Error(err))
 
-		if i != r.policy.MaxAttempts {
-			time.Sleep(delay)
-			delay = time.Duration(float32(delay) * r.policy.BackoffCoefficient)
-			if r.policy.MaxDelay != nil && delay > *r.policy.MaxDelay {
-				delay = *r.policy.MaxDelay
+		if i == r.policy.MaxAttempts {
+			break
+		}
+		if r.budget != nil && !r.budget.TryRetry() {
+			giveUps.WithLabelValues(name).Inc()
+			return result, multierr.Append(err, ErrBudgetExhausted)
+		}
+
+		delay = r.jitter(backoff, delay)
+		if after, ok := r.retryAfter(err); ok {
+			// the server knows better when it's ready, so the requested delay isn't limited by MaxDelay
+			if after > r.maxRetryAfter {
+				giveUps.WithLabelValues(name).Inc()
+				return result, multierr.Append(err, ErrRetryAfterTooLong)
 			}
+			delay = after
 		}
+		for _, hook := range r.onRetry {
+			hook(ctx, RetryInfo{Name: name, Attempt: i, Err: err, Delay: delay})
+		}
+		if waitErr := wait(ctx, delay); waitErr != nil {
+			giveUps.WithLabelValues(name).Inc()
+			return result, multierr.Append(err, waitErr)
+		}
+		backoff = r.limit(time.Duration(float32(backoff) * r.policy.BackoffCoefficient))
+	}
+
+	giveUps.WithLabelValues(name).Inc()
+	return result, &ExhaustedError{Name: name, Attempts: r.policy.MaxAttempts, Err: errs}
+}
+
+func attempt[T any](ctx context.Context, name string, i int, f func(ctx context.Context, attempt int) (T, error)) (T, error) {
+	ctx, span := tracer.StartSpan(ctx, name, trace.SpanKindInternal)
+	defer span.End()
+	span.AddAttribute("retry.attempt", i)
+
+	attempts.WithLabelValues(name).Inc()
+	result, err := f(ctx, i)
+	if err != nil {
+		return result, span.Error(err)
 	}
-	if err == nil {
-		logger.Debug("execution finished")
+	return result, nil
+}
+
+// ExhaustedError is returned when all attempts failed. Err combines errors of all attempts with multierr,
+// so errors.Is and errors.As match any of them.
+type ExhaustedError struct {
+	Name     string
+	Attempts int
+	Err      error
+}
+
+func (e *ExhaustedError) Error() string {
+	errs := multierr.Errors(e.Err)
+	if len(errs) == 0 {
+		return fmt.Sprintf("%s: %d attempts exhausted", e.Name, e.Attempts)
 	}
-	return err
+	return fmt.Sprintf("%s: %d attempts exhausted, last error: %v", e.Name, e.Attempts, errs[len(errs)-1])
+}
+
+func (e *ExhaustedError) Unwrap() error {
+	return e.Err
+}
+
+// Errors returns errors of all attempts in order.
+func (e *ExhaustedError) Errors() []error {
+	return multierr.Errors(e.Err)
+}
+
+func (r *Retrier) retryable(err error) bool {
+	if r.checkExcludedErrors(err) {
+		return false
+	}
+	return r.retryIf == nil || r.retryIf(err)
 }
 
 func (r *Retrier) checkExcludedErrors(err error) bool {
@@ -85,3 +244,28 @@ func (r *Retrier) checkExcludedErrors(err error) bool {
 	})
 	return ok
 }
+
+func (r *Retrier) limit(delay time.Duration) time.Duration {
+	if r.policy.MaxDelay != nil && delay > *r.policy.MaxDelay {
+		return *r.policy.MaxDelay
+	}
+	return delay
+}
+
+func wait(ctx context.Context, delay time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,275 @@
+package retrier
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-faster/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/multierr"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var errTest = errors.New("test")
+
+func policy(attempts int) RetryPolicy {
+	return RetryPolicy{MaxAttempts: attempts, StartDelay: time.Millisecond, BackoffCoefficient: 2}
+}
+
+func TestRetrier_Wrap(t *testing.T) {
+	errPermanent := errors.New("permanent")
+
+	testCases := []struct {
+		name     string
+		opts     []Opts
+		errs     []error
+		attempts int
+		err      error
+	}{
+		{name: "success", errs: []error{nil}, attempts: 1},
+		{name: "success after retries", errs: []error{errTest, errTest, nil}, attempts: 3},
+		{name: "attempts exhausted", errs: []error{errTest, errTest, errTest}, attempts: 3, err: errTest},
+		{
+			name:     "excluded error",
+			opts:     []Opts{WithExcludedErrors(errPermanent)},
+			errs:     []error{errors.Wrap(errPermanent, "wrapped")},
+			attempts: 1,
+			err:      errPermanent,
+		},
+		{
+			name: "not retryable",
+			opts: []Opts{WithRetryIf(func(err error) bool {
+				return !errors.Is(err, errPermanent)
+			})},
+			errs:     []error{errTest, errPermanent},
+			attempts: 2,
+			err:      errPermanent,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var attempts int
+			r := NewRetrier(append([]Opts{WithRetryPolicy(policy(3))}, tc.opts...)...)
+			err := r.Wrap(context.Background(), "test", func() error {
+				attempts++
+				return tc.errs[attempts-1]
+			})
+			assert.Equal(t, tc.attempts, attempts)
+			if tc.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestRetrier_ZeroPolicy(t *testing.T) {
+	var attempts int
+	err := NewRetrier(WithRetryPolicy(RetryPolicy{})).Wrap(context.Background(), "test", func() error {
+		attempts++
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestExhaustedError_Error(t *testing.T) {
+	assert.Equal(t, "test: 2 attempts exhausted, last error: test",
+		(&ExhaustedError{Name: "test", Attempts: 2, Err: multierr.Combine(errors.New("first"), errTest)}).Error())
+	assert.Equal(t, "test: 0 attempts exhausted", (&ExhaustedError{Name: "test"}).Error())
+}
+
+func TestRetrier_WrapCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := NewRetrier(WithRetryPolicy(RetryPolicy{MaxAttempts: 3, StartDelay: time.Hour}), WithOnRetry(func(context.Context, RetryInfo) {
+		cancel()
+	}))
+
+	start := time.Now()
+	err := r.Wrap(ctx, "test", func() error { return errTest })
+	assert.ErrorIs(t, err, errTest)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRetrier_Delays(t *testing.T) {
+	grpcErr, err := status.New(codes.ResourceExhausted, "quota").WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(5 * time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		jitter Jitter
+		err    error
+		delays []time.Duration
+	}{
+		{name: "no jitter", err: errTest, delays: []time.Duration{1, 2, 4, 4}},
+		// random returns the maximal value
+		{name: "full", jitter: JitterFull, err: errTest, delays: []time.Duration{1, 2, 4, 4}},
+		{name: "equal", jitter: JitterEqual, err: errTest, delays: []time.Duration{1, 2, 4, 4}},
+		{name: "decorrelated", jitter: JitterDecorrelated, err: errTest, delays: []time.Duration{3, 4, 4, 4}},
+		{name: "retry after", err: NewRetryAfterError(errTest, 7*time.Millisecond), delays: []time.Duration{7, 7, 7, 7}},
+		{name: "grpc retry info", err: grpcErr.Err(), delays: []time.Duration{5, 5, 5, 5}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := policy(5)
+			p.MaxDelay = lo.ToPtr(4 * time.Millisecond)
+			p.Jitter = tc.jitter
+
+			var delays []time.Duration
+			r := NewRetrier(WithRetryPolicy(p), WithOnRetry(func(_ context.Context, info RetryInfo) {
+				delays = append(delays, info.Delay/time.Millisecond)
+			}))
+			r.random = func(n int64) int64 { return n - 1 }
+
+			assert.Error(t, r.Wrap(context.Background(), "test", func() error { return tc.err }))
+			assert.Equal(t, tc.delays, delays)
+		})
+	}
+}
+
+func TestRetrier_RetryAfterTooLong(t *testing.T) {
+	var attempts int
+	r := NewRetrier(WithRetryPolicy(policy(3)), WithMaxRetryAfter(time.Second))
+
+	start := time.Now()
+	err := r.Wrap(context.Background(), "test", func() error {
+		attempts++
+		return NewRetryAfterError(errTest, time.Hour)
+	})
+	assert.ErrorIs(t, err, errTest)
+	assert.ErrorIs(t, err, ErrRetryAfterTooLong)
+	assert.Equal(t, 1, attempts)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		value string
+		delay time.Duration
+		ok    bool
+	}{
+		{value: "120", delay: 2 * time.Minute, ok: true},
+		{value: now.Add(time.Minute).Format(http.TimeFormat), delay: time.Minute, ok: true},
+		{value: now.Add(-time.Minute).Format(http.TimeFormat), delay: 0, ok: true},
+		{value: "-1"},
+		{value: "soon"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			delay, ok := ParseRetryAfter(tc.value, now)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.delay, delay)
+		})
+	}
+}
+
+func TestDo(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	// counters are global, so only their increments are checked
+	counter := func(c *prometheus.CounterVec, name string) float64 {
+		return testutil.ToFloat64(c.WithLabelValues(name))
+	}
+	var (
+		attemptsBefore  = counter(attempts, "do_success")
+		successesBefore = counter(successes, "do_success")
+		giveUpsBefore   = counter(giveUps, "do_success")
+		exhaustedBefore = counter(giveUps, "do_exhausted")
+	)
+
+	r := NewRetrier(WithRetryPolicy(policy(3)))
+
+	var seen []int
+	result, err := Do(context.Background(), r, "do_success", func(_ context.Context, attempt int) (string, error) {
+		seen = append(seen, attempt)
+		if attempt < 2 {
+			return "", errTest
+		}
+		return "done", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "done", result)
+	assert.Equal(t, []int{1, 2}, seen)
+	assert.InDelta(t, 2, counter(attempts, "do_success")-attemptsBefore, 0)
+	assert.InDelta(t, 1, counter(successes, "do_success")-successesBefore, 0)
+	assert.InDelta(t, 0, counter(giveUps, "do_success")-giveUpsBefore, 0)
+	assert.Len(t, recorder.Ended(), 2)
+
+	errFirst := errors.New("first")
+	_, err = Do(context.Background(), r, "do_exhausted", func(_ context.Context, attempt int) (int, error) {
+		if attempt == 1 {
+			return 0, errFirst
+		}
+		return 0, errTest
+	})
+	var exhausted *ExhaustedError
+	require.ErrorAs(t, err, &exhausted)
+	assert.Equal(t, 3, exhausted.Attempts)
+	assert.Len(t, exhausted.Errors(), 3)
+	assert.ErrorIs(t, err, errFirst)
+	assert.ErrorIs(t, err, errTest)
+	assert.InDelta(t, 1, counter(giveUps, "do_exhausted")-exhaustedBefore, 0)
+}
+
+func TestBudget(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	budget := NewBudget(0.2, 0, 10*time.Second)
+	budget.now = func() time.Time { return now }
+
+	r := NewRetrier(WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BackoffCoefficient: 1}), WithBudget(budget))
+	failing := func() error { return errTest }
+
+	// 10 calls allow 2 retries
+	for i := 0; i < 9; i++ {
+		require.NoError(t, r.Wrap(context.Background(), "test", func() error { return nil }))
+	}
+	var exhausted *ExhaustedError
+	require.ErrorAs(t, r.Wrap(context.Background(), "test", failing), &exhausted)
+	assert.Len(t, exhausted.Errors(), 3)
+
+	err := r.Wrap(context.Background(), "test", failing)
+	assert.ErrorIs(t, err, ErrBudgetExhausted)
+	assert.ErrorIs(t, err, errTest)
+
+	// calls and retries expire with the window
+	now = now.Add(10 * time.Second)
+	budget.RecordCall()
+	assert.False(t, budget.TryRetry())
+	for i := 0; i < 4; i++ {
+		budget.RecordCall()
+	}
+	assert.True(t, budget.TryRetry())
+	assert.False(t, budget.TryRetry())
+}
+
+func TestBudget_ShortWindow(t *testing.T) {
+	budget := NewBudget(0.5, 0, time.Nanosecond)
+	budget.RecordCall()
+	budget.RecordCall()
+	assert.True(t, budget.TryRetry())
+	assert.False(t, budget.TryRetry())
+}
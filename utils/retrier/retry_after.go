@@ -0,0 +1,73 @@
+package retrier
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-faster/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrRetryAfterTooLong is returned with the last error when the server asks to wait longer than allowed.
+var ErrRetryAfterTooLong = errors.New("requested retry delay is too long")
+
+// RetryAfterError is an error with the delay the server asked to wait before retrying.
+type RetryAfterError struct {
+	Err   error
+	Delay time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error() + ", retry after " + e.Delay.String()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// NewRetryAfterError annotates err with the delay, e.g. parsed from Retry-After header of HTTP 429 response.
+func NewRetryAfterError(err error, delay time.Duration) error {
+	return &RetryAfterError{Err: err, Delay: delay}
+}
+
+// RetryAfter extracts the delay from RetryAfterError or RetryInfo details of gRPC RESOURCE_EXHAUSTED
+// and UNAVAILABLE statuses.
+func RetryAfter(err error) (time.Duration, bool) {
+	var retryAfter *RetryAfterError
+	if errors.As(err, &retryAfter) {
+		return retryAfter.Delay, true
+	}
+
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if !errors.As(err, &grpcErr) {
+		return 0, false
+	}
+	st := grpcErr.GRPCStatus()
+	if st.Code() != codes.ResourceExhausted && st.Code() != codes.Unavailable {
+		return 0, false
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok && info.GetRetryDelay() != nil {
+			return info.GetRetryDelay().AsDuration(), true
+		}
+	}
+	return 0, false
+}
+
+// ParseRetryAfter parses value of Retry-After header, which is either a number of seconds or an HTTP date.
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	return max(date.Sub(now), 0), true
+}